@@ -37,12 +37,23 @@ func executeSourceCode(filename string, options *Options) {
 		log.Fatal(err)
 	}
 
-	executeExpression(string(buffer), options.DumpAST)
+	interpreter := scheme.NewInterpreter("")
+	interpreter.ReloadSourceFile(filename, string(buffer))
+	evalAndPrintErrors(interpreter, options.DumpAST)
 }
 
 func executeExpression(expression string, dumpAST bool) {
-	interpreter := scheme.NewInterpreter(expression)
-	interpreter.PrintErrors(dumpAST)
+	evalAndPrintErrors(scheme.NewInterpreter(expression), dumpAST)
+}
+
+func evalAndPrintErrors(interpreter *scheme.Interpreter, dumpAST bool) {
+	_, err := interpreter.Eval(dumpAST)
+	if dumpAST {
+		fmt.Printf("\n*** Result ***\n")
+	}
+	if err != nil {
+		printError(err)
+	}
 }
 
 func invokeInteractiveShell(options *Options) {
@@ -69,7 +80,15 @@ func invokeInteractiveShell(options *Options) {
 			indentLevel = interpreter.IndentLevel()
 			if indentLevel == 0 {
 				mainInterpreter.ReloadSourceCode(expression)
-				mainInterpreter.PrintResults(options.DumpAST)
+				result, err := mainInterpreter.Eval(options.DumpAST)
+				if options.DumpAST {
+					fmt.Printf("\n*** Result ***\n")
+				}
+				if err != nil {
+					printError(err)
+				} else if result != nil {
+					fmt.Println(result)
+				}
 				break
 			} else if indentLevel < 0 {
 				fmt.Println("*** ERROR: extra close parentheses")
@@ -80,6 +99,13 @@ func invokeInteractiveShell(options *Options) {
 	}
 }
 
+func printError(err error) {
+	fmt.Printf("*** ERROR: %s\n", err)
+	if schemeError, ok := err.(*scheme.SchemeError); ok {
+		fmt.Print(schemeError.Backtrace())
+	}
+}
+
 func shellPrompt(indentLevel int) string {
 	if indentLevel == 0 {
 		return "gosick> "
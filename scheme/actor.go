@@ -6,43 +6,50 @@ import (
 
 type Actor struct {
 	ObjectBase
-	functions    map[string]func([]Object)
-	receiver     chan []Object
-	localBinding Binding
+	functions   map[string]func([]Object)
+	receiver    chan []Object
+	environment *Environment
 }
 
-func NewActor(parent Object) *Actor {
+// Actor has its own environment frame where "self" and message arguments are bound.
+// Messages are evaluated in the goroutine of actor, so that the frame starts a new evaluation.
+func NewActor(environment *Environment, size int) *Actor {
 	actor := &Actor{
-		ObjectBase:   ObjectBase{parent: parent},
-		functions:    make(map[string]func([]Object)),
-		receiver:     make(chan []Object),
-		localBinding: make(Binding),
+		ObjectBase:  ObjectBase{parent: nil},
+		functions:   make(map[string]func([]Object)),
+		receiver:    make(chan []Object),
+		environment: newEvaluation(environment),
 	}
-	actor.localBinding["self"] = actor
+	actor.environment.values = make([]Object, size)
+	actor.environment.put(0, actor)
 	return actor
 }
 
-func (a *Actor) Eval() Object {
-	return a
-}
-
-func (a *Actor) Invoke(argument Object) Object {
-	assertListMinimum(argument, 1)
-	elements := argument.(*Pair).Elements()
+// Invoke actor's method, which is given as an unevaluated identifier.
+// Message arguments are evaluated by compiled codes of arguments.
+func (a *Actor) Invoke(arguments Object, codes []Code, environment *Environment) Object {
+	assertListMinimum(arguments, 1)
+	elements := arguments.(*Pair).Elements()
 	switch elements[0].(type) {
 	case *Variable:
 		switch elements[0].(*Variable).identifier {
 		case "start":
 			go a.Start()
 		case "!":
-			a.receiver <- elements[1:]
+			return evalCodesThen(codes[1:], environment, a.send)
 		default:
-			runtimeError("unexpected method for actor: %s", elements[0].(*Variable).identifier)
+			raiseErrorIn(environment, errorKindRuntime, "unexpected method for actor: %s", elements[0].(*Variable).identifier)
 		}
 	}
 	return undef
 }
 
+// Send a message to the receiver of actor.
+func (a *Actor) send(message []Object, environment *Environment) Object {
+	a.receiver <- message
+	return undef
+}
+
 func (a *Actor) Start() {
 	for {
 		select {
@@ -52,7 +59,7 @@ func (a *Actor) Start() {
 			}
 			assertObjectType(received[0], "string")
 
-			a.functions[received[0].(*String).text](received[1:])
+			a.functions[received[0].(*String).text()](received[1:])
 		}
 	}
 }
@@ -63,29 +70,3 @@ func (a *Actor) String() string {
 	}
 	return fmt.Sprintf("#<actor %s>", a.Bounder())
 }
-
-func (a *Actor) tryDefine(variable Object, object Object) {
-	if variable.isVariable() {
-		a.localBinding[variable.(*Variable).identifier] = object
-	}
-}
-
-func (a *Actor) define(identifier string, object Object) {
-	a.localBinding[identifier] = object
-}
-
-func (a *Actor) set(identifier string, object Object) {
-	if a.localBinding[identifier] == nil {
-		if a.parent == nil {
-			runtimeError("symbol not defined")
-		} else {
-			a.parent.set(identifier, object)
-		}
-	} else {
-		a.localBinding[identifier] = object
-	}
-}
-
-func (a *Actor) binding() Binding {
-	return a.localBinding
-}
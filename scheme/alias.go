@@ -0,0 +1,93 @@
+// Alias is an identifier renamed by a syntax-rules template.
+// Every symbol inserted by a template is replaced with a fresh alias,
+// so that a binding introduced by a macro never captures user's variable.
+// A free alias is resolved by its original name in the scope where the
+// macro is defined.
+
+package scheme
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+var aliasCount int64
+
+type Alias struct {
+	ObjectBase
+	identifier string // unique name which is bound by binding forms
+	original   Object // *Symbol or *Alias which is renamed
+	scope      *Scope // resolves free alias
+}
+
+func NewAlias(original Object, scope *Scope) *Alias {
+	return &Alias{
+		identifier: fmt.Sprintf("%s#%d", identifierName(original), atomic.AddInt64(&aliasCount, 1)),
+		original:   original,
+		scope:      scope,
+	}
+}
+
+func (a *Alias) String() string {
+	return identifierName(a)
+}
+
+func isIdentifier(object Object) bool {
+	switch object.(type) {
+	case *Symbol, *Alias:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns the name of symbol which is written in source code.
+func identifierName(object Object) string {
+	switch object.(type) {
+	case *Symbol:
+		return object.(*Symbol).identifier
+	case *Alias:
+		return identifierName(object.(*Alias).original)
+	case *Variable:
+		if object.(*Variable).alias != nil {
+			return identifierName(object.(*Variable).alias)
+		}
+		return object.(*Variable).identifier
+	default:
+		return ""
+	}
+}
+
+// Replace aliases in given data with their original symbols.
+// This is used when data is quoted.
+func unwrapAliases(datum Object) Object {
+	switch datum.(type) {
+	case *Alias:
+		return NewSymbol(identifierName(datum))
+	case *Pair:
+		if datum.isNull() {
+			return datum
+		}
+		pair := datum.(*Pair)
+		car, cdr := unwrapAliases(pair.Car), unwrapAliases(pair.Cdr)
+		if car == pair.Car && cdr == pair.Cdr {
+			// data without aliases is kept as it is
+			return datum
+		}
+		return &Pair{Car: car, Cdr: cdr}
+	case *Vector:
+		vector := datum.(*Vector)
+		elements := make([]Object, len(vector.elements))
+		unwrapped := false
+		for index, element := range vector.elements {
+			elements[index] = unwrapAliases(element)
+			unwrapped = unwrapped || elements[index] != element
+		}
+		if !unwrapped {
+			return datum
+		}
+		return NewVector(elements)
+	default:
+		return datum
+	}
+}
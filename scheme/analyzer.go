@@ -0,0 +1,93 @@
+// Analyzer converts data read by parser into expressions of AST.
+// A list is analyzed as an application and a symbol as a variable, and
+// a datum quoted by 'datum becomes a constant as it is. The datum of
+// (quote datum) is not analyzed either, and quote is resolved by its binding
+// when it is compiled. Macro uses are expanded when they are compiled, and
+// their expansions are analyzed again.
+
+package scheme
+
+type analyzer struct {
+	locations  map[*Pair]*Location // locations of symbols recorded by lexer
+	quotations map[*Pair]bool      // lists of 'datum recorded by lexer
+}
+
+// Convert data into an expression of AST whose parent is given object.
+// This is the inverse of toDatum.
+func toExpression(datum Object, parent Object) Object {
+	return (&analyzer{}).expression(datum, nil, parent)
+}
+
+// Analyze datum, which is located at location if it cannot have its own location.
+func (a *analyzer) expression(datum Object, location *Location, parent Object) Object {
+	switch datum.(type) {
+	case *Symbol:
+		variable := NewVariable(datum.(*Symbol).identifier, parent)
+		variable.setLocation(location)
+		return variable
+	case *Alias:
+		variable := NewVariable(datum.(*Alias).identifier, parent)
+		variable.alias = datum.(*Alias)
+		variable.setLocation(location)
+		return variable
+	case *Pair:
+		if datum.isNull() {
+			return Null
+		}
+		pair := datum.(*Pair)
+		if a.quotations[pair] {
+			return pair.Cdr.(*Pair).Car
+		}
+
+		application := NewApplication(parent)
+		application.setLocation(pair.Location())
+		application.procedure = a.element(pair, application)
+		if isQuotation(application) {
+			application.arguments = pair.Cdr
+		} else {
+			application.arguments = a.list(pair.Cdr, application)
+		}
+		return application
+	default:
+		return datum
+	}
+}
+
+// Analyze the car of cell, which is located at the cell.
+func (a *analyzer) element(cell *Pair, parent Object) Object {
+	location, ok := a.locations[cell]
+	if !ok {
+		location = cell.Location()
+	}
+	expression := a.expression(cell.Car, location, parent)
+	expression.setParent(parent)
+	return expression
+}
+
+func (a *analyzer) list(datum Object, parent Object) Object {
+	if datum.isNull() {
+		return Null
+	} else if !datum.isPair() {
+		return a.expression(datum, nil, parent)
+	}
+
+	pair := NewPair(parent)
+	pair.setLocation(datum.Location())
+	pair.Car = a.element(datum.(*Pair), pair)
+	pair.Cdr = a.list(datum.(*Pair).Cdr, pair)
+	return pair
+}
+
+// Returns true if application is a quote form, whose arguments are data as they are read.
+func isQuotation(application *Application) bool {
+	return identifierName(application.procedure) == "quote"
+}
+
+// Returns a quote form whose arguments are analyzed, for quote bound to other than quote syntax.
+func analyzeQuotation(application *Application) *Application {
+	analyzed := NewApplication(application.Parent())
+	analyzed.setLocation(application.Location())
+	analyzed.procedure = application.procedure
+	analyzed.arguments = (&analyzer{}).list(application.arguments, analyzed)
+	return analyzed
+}
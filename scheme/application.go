@@ -4,14 +4,25 @@
 
 package scheme
 
+var (
+	abbreviations = map[string]string{
+		"quote":            "'",
+		"quasiquote":       "`",
+		"unquote":          ",",
+		"unquote-splicing": ",@",
+	}
+)
+
 type Application struct {
 	ObjectBase
 	procedure Object
 	arguments Object
 }
 
-type Invoker interface {
-	Invoke(Object) Object
+// Expander is a macro transformer which receives arguments as data
+// and the scope of macro use, and returns an expansion.
+type Expander interface {
+	Expand(Object, *Scope) Object
 }
 
 func NewApplication(parent Object) *Application {
@@ -20,34 +31,22 @@ func NewApplication(parent Object) *Application {
 	}
 }
 
-func (a *Application) Eval() Object {
-	evaledObject := a.procedure.Eval()
-
-	switch evaledObject.(type) {
-	case Invoker:
-		return evaledObject.(Invoker).Invoke(a.arguments)
-	default:
-		runtimeError("invalid application")
-		return nil
-	}
-}
-
 func (a *Application) String() string {
-	// Exceptional handling for special form: quote
-	list := a.toList()
-	firstObject := list.ElementAt(0)
-	if firstObject.isVariable() && firstObject.(*Variable).content() == builtinSyntaxes["quote"] {
-		if a.arguments.isNull() {
-			return "(quote)"
-		} else {
-			return "'" + a.arguments.(*Pair).ElementAt(0).String()
+	// Exceptional handling for special forms: quote, quasiquote, unquote and unquote-splicing
+	if prefix, ok := abbreviations[identifierName(a.procedure)]; ok {
+		if a.arguments.isPair() && a.arguments.(*Pair).ListLength() == 1 {
+			return prefix + a.arguments.(*Pair).ElementAt(0).String()
 		}
 	}
 
-	return list.String()
+	return a.toList().String()
 }
 
 func (a *Application) toList() *Pair {
+	if isQuotation(a) {
+		// a list in syntax form such as a binding of quote has subforms
+		a = analyzeQuotation(a)
+	}
 	list := NewPair(a.Parent())
 	list.Car = a.procedure
 	list.Car.setParent(list)
@@ -0,0 +1,85 @@
+// Backtrace is a list of frames of procedure calls which are being evaluated.
+//
+// Each non-tail application pushes a frame to its evaluation while it is
+// evaluated, and each application records its procedure, arguments and
+// call-site to the current frame. Since a tail call replaces the frame of its
+// caller, a backtrace has the same frames as Go's stack of evaluation.
+
+package scheme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Frame is a procedure call in backtrace.
+type Frame struct {
+	Procedure Object // nil while procedure and arguments are evaluated
+	Arguments []Object
+	Location  *Location // call-site, nil if unknown
+}
+
+type callFrame struct {
+	Frame
+	next *callFrame // frame of caller
+}
+
+// Returns a name of procedure by the variable bound to it.
+func (f *Frame) Name() string {
+	if f.Procedure == nil {
+		return "#f"
+	} else if bounder := f.Procedure.Bounder(); bounder != nil {
+		return bounder.identifier
+	}
+	return f.Procedure.String()
+}
+
+func (f *Frame) String() string {
+	tokens := []string{f.Name()}
+	for _, argument := range f.Arguments {
+		tokens = append(tokens, argument.String())
+	}
+	if f.Location == nil {
+		return fmt.Sprintf("(%s)", strings.Join(tokens, " "))
+	}
+	return fmt.Sprintf("(%s) at %s", strings.Join(tokens, " "), f.Location)
+}
+
+// Push a frame of an application at location, and returns it to be popped.
+func pushFrame(environment *Environment, location *Location) *callFrame {
+	evaluation := environment.evaluation
+	frame := &callFrame{Frame: Frame{Location: location}, next: evaluation.frame}
+	evaluation.frame = frame
+	return frame
+}
+
+func popFrame(environment *Environment, frame *callFrame) {
+	environment.evaluation.frame = frame.next
+}
+
+// Record a procedure call to the current frame.
+func recordCall(environment *Environment, procedure Object, arguments []Object, location *Location) {
+	if frame := environment.evaluation.frame; frame != nil {
+		frame.Procedure, frame.Arguments, frame.Location = procedure, arguments, location
+	}
+}
+
+// Returns frames of the evaluation of environment from the innermost one.
+func currentBacktrace(environment *Environment) []Frame {
+	frames := []Frame{}
+	for frame := environment.evaluation.frame; frame != nil; frame = frame.next {
+		if frame.Procedure != nil {
+			frames = append(frames, frame.Frame)
+		}
+	}
+	return frames
+}
+
+// Format frames as lines with their indices.
+func formatBacktrace(frames []Frame) string {
+	lines := []string{}
+	for index, frame := range frames {
+		lines = append(lines, fmt.Sprintf("%3d  %s\n", index, &frame))
+	}
+	return strings.Join(lines, "")
+}
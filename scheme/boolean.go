@@ -29,10 +29,6 @@ func NewBoolean(value interface{}, options ...Object) (boolean *Boolean) {
 	return
 }
 
-func (b *Boolean) Eval() Object {
-	return b
-}
-
 func (b *Boolean) String() string {
 	if b.value == true {
 		return "#t"
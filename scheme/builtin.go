@@ -5,73 +5,294 @@ package scheme
 import (
 	"fmt"
 	"io/ioutil"
+	"math"
+	"math/big"
+	"math/cmplx"
 	"os"
 	"strings"
+	"unicode"
 )
 
 var (
 	builtinProcedures = Binding{
-		"+":              NewSubroutine(plusSubr),
-		"-":              NewSubroutine(minusSubr),
-		"*":              NewSubroutine(multiplySubr),
-		"/":              NewSubroutine(divideSubr),
-		"=":              NewSubroutine(equalSubr),
-		"<":              NewSubroutine(lessThanSubr),
-		"<=":             NewSubroutine(lessEqualSubr),
-		">":              NewSubroutine(greaterThanSubr),
-		">=":             NewSubroutine(greaterEqualSubr),
-		"append":         NewSubroutine(appendSubr),
-		"boolean?":       NewSubroutine(isBooleanSubr),
-		"car":            NewSubroutine(carSubr),
-		"cdr":            NewSubroutine(cdrSubr),
-		"cons":           NewSubroutine(consSubr),
-		"dump":           NewSubroutine(dumpSubr),
-		"eq?":            NewSubroutine(isEqSubr),
-		"equal?":         NewSubroutine(isEqualSubr),
-		"exit":           NewSubroutine(exitSubr),
-		"last":           NewSubroutine(lastSubr),
-		"length":         NewSubroutine(lengthSubr),
-		"list":           NewSubroutine(listSubr),
-		"list?":          NewSubroutine(isListSubr),
-		"load":           NewSubroutine(loadSubr),
-		"memq":           NewSubroutine(memqSubr),
-		"neq?":           NewSubroutine(isNeqSubr),
-		"number?":        NewSubroutine(isNumberSubr),
-		"number->string": NewSubroutine(numberToStringSubr),
-		"pair?":          NewSubroutine(isPairSubr),
-		"print":          NewSubroutine(printSubr),
-		"procedure?":     NewSubroutine(isProcedureSubr),
-		"set-car!":       NewSubroutine(setCarSubr),
-		"set-cdr!":       NewSubroutine(setCdrSubr),
-		"string?":        NewSubroutine(isStringSubr),
-		"string-append":  NewSubroutine(stringAppendSubr),
-		"string->number": NewSubroutine(stringToNumberSubr),
-		"string->symbol": NewSubroutine(stringToSymbolSubr),
-		"symbol?":        NewSubroutine(isSymbolSubr),
-		"symbol->string": NewSubroutine(symbolToStringSubr),
-		"write":          NewSubroutine(writeSubr),
+		"+":                              NewSubroutine(plusSubr),
+		"-":                              NewSubroutine(minusSubr),
+		"*":                              NewSubroutine(multiplySubr),
+		"/":                              NewSubroutine(divideSubr),
+		"=":                              NewSubroutine(equalSubr),
+		"<":                              NewSubroutine(lessThanSubr),
+		"<=":                             NewSubroutine(lessEqualSubr),
+		">":                              NewSubroutine(greaterThanSubr),
+		">=":                             NewSubroutine(greaterEqualSubr),
+		"abs":                            NewSubroutine(absSubr),
+		"acos":                           NewSubroutine(acosSubr),
+		"angle":                          NewSubroutine(angleSubr),
+		"append":                         NewSubroutine(appendSubr),
+		"asin":                           NewSubroutine(asinSubr),
+		"atan":                           NewSubroutine(atanSubr),
+		"boolean?":                       NewSubroutine(isBooleanSubr),
+		"call-with-current-continuation": NewSubroutine(callWithCurrentContinuationSubr),
+		"call-with-escape-continuation":  NewSubroutine(callWithEscapeContinuationSubr),
+		"call-with-input-file":           NewSubroutine(callWithInputFileSubr),
+		"call-with-output-file":          NewSubroutine(callWithOutputFileSubr),
+		"call-with-output-string":        NewSubroutine(callWithOutputStringSubr),
+		"call-with-values":               NewSubroutine(callWithValuesSubr),
+		"call/cc":                        NewSubroutine(callWithCurrentContinuationSubr),
+		"call/ec":                        NewSubroutine(callWithEscapeContinuationSubr),
+		"car":                            NewSubroutine(carSubr),
+		"cdr":                            NewSubroutine(cdrSubr),
+		"ceiling":                        NewSubroutine(ceilingSubr),
+		"char?":                          NewSubroutine(isCharSubr),
+		"char->integer":                  NewSubroutine(charToIntegerSubr),
+		"char-alphabetic?":               NewSubroutine(isCharAlphabeticSubr),
+		"char-ci<?":                      NewSubroutine(charCiLessThanSubr),
+		"char-ci<=?":                     NewSubroutine(charCiLessEqualSubr),
+		"char-ci=?":                      NewSubroutine(charCiEqualSubr),
+		"char-ci>?":                      NewSubroutine(charCiGreaterThanSubr),
+		"char-ci>=?":                     NewSubroutine(charCiGreaterEqualSubr),
+		"char-downcase":                  NewSubroutine(charDowncaseSubr),
+		"char-foldcase":                  NewSubroutine(charFoldcaseSubr),
+		"char-lower-case?":               NewSubroutine(isCharLowerCaseSubr),
+		"char-numeric?":                  NewSubroutine(isCharNumericSubr),
+		"char-upcase":                    NewSubroutine(charUpcaseSubr),
+		"char-upper-case?":               NewSubroutine(isCharUpperCaseSubr),
+		"char-whitespace?":               NewSubroutine(isCharWhitespaceSubr),
+		"char<?":                         NewSubroutine(charLessThanSubr),
+		"char<=?":                        NewSubroutine(charLessEqualSubr),
+		"char=?":                         NewSubroutine(charEqualSubr),
+		"char>?":                         NewSubroutine(charGreaterThanSubr),
+		"char>=?":                        NewSubroutine(charGreaterEqualSubr),
+		"close-input-port":               NewSubroutine(closePortSubr),
+		"close-output-port":              NewSubroutine(closePortSubr),
+		"close-port":                     NewSubroutine(closePortSubr),
+		"complex?":                       NewSubroutine(isComplexSubr),
+		"cons":                           NewSubroutine(consSubr),
+		"cos":                            NewSubroutine(cosSubr),
+		"current-backtrace":              NewSubroutine(currentBacktraceSubr),
+		"current-error-port":             NewSubroutine(currentErrorPortSubr),
+		"current-input-port":             NewSubroutine(currentInputPortSubr),
+		"current-output-port":            NewSubroutine(currentOutputPortSubr),
+		"denominator":                    NewSubroutine(denominatorSubr),
+		"digit-value":                    NewSubroutine(digitValueSubr),
+		"display":                        NewSubroutine(displaySubr),
+		"dump":                           NewSubroutine(dumpSubr),
+		"eof-object":                     NewSubroutine(eofObjectSubr),
+		"eof-object?":                    NewSubroutine(isEOFObjectSubr),
+		"eq?":                            NewSubroutine(isEqSubr),
+		"equal?":                         NewSubroutine(isEqualSubr),
+		"eqv?":                           NewSubroutine(isEqSubr),
+		"error":                          NewSubroutine(errorSubr),
+		"error-object?":                  NewSubroutine(isErrorObjectSubr),
+		"error-object-irritants":         NewSubroutine(errorObjectIrritantsSubr),
+		"error-object-kind":              NewSubroutine(errorObjectKindSubr),
+		"error-object-message":           NewSubroutine(errorObjectMessageSubr),
+		"even?":                          NewSubroutine(isEvenSubr),
+		"exact":                          NewSubroutine(exactSubr),
+		"exact?":                         NewSubroutine(isExactSubr),
+		"exact->inexact":                 NewSubroutine(inexactSubr),
+		"exact-integer?":                 NewSubroutine(isExactIntegerSubr),
+		"exact-integer-sqrt":             NewSubroutine(exactIntegerSqrtSubr),
+		"exit":                           NewSubroutine(exitSubr),
+		"exp":                            NewSubroutine(expSubr),
+		"expt":                           NewSubroutine(exptSubr),
+		"file-error?":                    NewSubroutine(isFileErrorSubr),
+		"finite?":                        NewSubroutine(isFiniteSubr),
+		"floor":                          NewSubroutine(floorSubr),
+		"floor-quotient":                 NewSubroutine(floorQuotientSubr),
+		"floor-remainder":                NewSubroutine(moduloSubr),
+		"floor/":                         NewSubroutine(floorDivideSubr),
+		"flush-output-port":              NewSubroutine(flushOutputPortSubr),
+		"gcd":                            NewSubroutine(gcdSubr),
+		"get-output-string":              NewSubroutine(getOutputStringSubr),
+		"hash-table?":                    NewSubroutine(isHashTableSubr),
+		"hash-table->alist":              NewSubroutine(hashTableToAlistSubr),
+		"hash-table-contains?":           NewSubroutine(hashTableContainsSubr),
+		"hash-table-count":               NewSubroutine(hashTableCountSubr),
+		"hash-table-delete!":             NewSubroutine(hashTableDeleteSubr),
+		"hash-table-keys":                NewSubroutine(hashTableKeysSubr),
+		"hash-table-ref":                 NewSubroutine(hashTableRefSubr),
+		"hash-table-ref/default":         NewSubroutine(hashTableRefDefaultSubr),
+		"hash-table-set!":                NewSubroutine(hashTableSetSubr),
+		"hash-table-update!":             NewSubroutine(hashTableUpdateSubr),
+		"hash-table-values":              NewSubroutine(hashTableValuesSubr),
+		"hash-table-walk":                NewSubroutine(hashTableWalkSubr),
+		"imag-part":                      NewSubroutine(imagPartSubr),
+		"inexact":                        NewSubroutine(inexactSubr),
+		"inexact?":                       NewSubroutine(isInexactSubr),
+		"inexact->exact":                 NewSubroutine(exactSubr),
+		"infinite?":                      NewSubroutine(isInfiniteSubr),
+		"input-port?":                    NewSubroutine(isInputPortSubr),
+		"integer?":                       NewSubroutine(isIntegerSubr),
+		"integer->char":                  NewSubroutine(integerToCharSubr),
+		"last":                           NewSubroutine(lastSubr),
+		"lcm":                            NewSubroutine(lcmSubr),
+		"length":                         NewSubroutine(lengthSubr),
+		"list":                           NewSubroutine(listSubr),
+		"list?":                          NewSubroutine(isListSubr),
+		"list->string":                   NewSubroutine(listToStringSubr),
+		"list->vector":                   NewSubroutine(listToVectorSubr),
+		"load":                           NewSubroutine(loadSubr),
+		"log":                            NewSubroutine(logSubr),
+		"macroexpand":                    NewSubroutine(macroexpandSubr),
+		"macroexpand-1":                  NewSubroutine(macroexpand1Subr),
+		"magnitude":                      NewSubroutine(magnitudeSubr),
+		"make-hash-table":                NewSubroutine(makeHashTableSubr),
+		"make-polar":                     NewSubroutine(makePolarSubr),
+		"make-rectangular":               NewSubroutine(makeRectangularSubr),
+		"make-string":                    NewSubroutine(makeStringSubr),
+		"make-vector":                    NewSubroutine(makeVectorSubr),
+		"max":                            NewSubroutine(maxSubr),
+		"memq":                           NewSubroutine(memqSubr),
+		"min":                            NewSubroutine(minSubr),
+		"modulo":                         NewSubroutine(moduloSubr),
+		"nan?":                           NewSubroutine(isNanSubr),
+		"negative?":                      NewSubroutine(isNegativeSubr),
+		"neq?":                           NewSubroutine(isNeqSubr),
+		"newline":                        NewSubroutine(newlineSubr),
+		"number?":                        NewSubroutine(isNumberSubr),
+		"number->string":                 NewSubroutine(numberToStringSubr),
+		"numerator":                      NewSubroutine(numeratorSubr),
+		"odd?":                           NewSubroutine(isOddSubr),
+		"open-input-file":                NewSubroutine(openInputFileSubr),
+		"open-input-string":              NewSubroutine(openInputStringSubr),
+		"open-output-file":               NewSubroutine(openOutputFileSubr),
+		"open-output-string":             NewSubroutine(openOutputStringSubr),
+		"output-port?":                   NewSubroutine(isOutputPortSubr),
+		"pair?":                          NewSubroutine(isPairSubr),
+		"peek-char":                      NewSubroutine(peekCharSubr),
+		"port?":                          NewSubroutine(isPortSubr),
+		"positive?":                      NewSubroutine(isPositiveSubr),
+		"print":                          NewSubroutine(printSubr),
+		"procedure?":                     NewSubroutine(isProcedureSubr),
+		"quotient":                       NewSubroutine(quotientSubr),
+		"raise":                          NewSubroutine(raiseSubr),
+		"raise-continuable":              NewSubroutine(raiseContinuableSubr),
+		"rational?":                      NewSubroutine(isRationalSubr),
+		"read":                           NewSubroutine(readSubr),
+		"read-char":                      NewSubroutine(readCharSubr),
+		"read-line":                      NewSubroutine(readLineSubr),
+		"read-string":                    NewSubroutine(readStringSubr),
+		"real?":                          NewSubroutine(isRealSubr),
+		"real-part":                      NewSubroutine(realPartSubr),
+		"remainder":                      NewSubroutine(remainderSubr),
+		"round":                          NewSubroutine(roundSubr),
+		"set-car!":                       NewSubroutine(setCarSubr),
+		"set-cdr!":                       NewSubroutine(setCdrSubr),
+		"sin":                            NewSubroutine(sinSubr),
+		"sqrt":                           NewSubroutine(sqrtSubr),
+		"square":                         NewSubroutine(squareSubr),
+		"string":                         NewSubroutine(stringSubr),
+		"string?":                        NewSubroutine(isStringSubr),
+		"string->list":                   NewSubroutine(stringToListSubr),
+		"string-append":                  NewSubroutine(stringAppendSubr),
+		"string->number":                 NewSubroutine(stringToNumberSubr),
+		"string->symbol":                 NewSubroutine(stringToSymbolSubr),
+		"string-ci<?":                    NewSubroutine(stringCiLessThanSubr),
+		"string-ci<=?":                   NewSubroutine(stringCiLessEqualSubr),
+		"string-ci=?":                    NewSubroutine(stringCiEqualSubr),
+		"string-ci>?":                    NewSubroutine(stringCiGreaterThanSubr),
+		"string-ci>=?":                   NewSubroutine(stringCiGreaterEqualSubr),
+		"string-contains":                NewSubroutine(stringContainsSubr),
+		"string-copy":                    NewSubroutine(stringCopySubr),
+		"string-downcase":                NewSubroutine(stringDowncaseSubr),
+		"string-fill!":                   NewSubroutine(stringFillSubr),
+		"string-foldcase":                NewSubroutine(stringFoldcaseSubr),
+		"string-for-each":                NewSubroutine(stringForEachSubr),
+		"string-index":                   NewSubroutine(stringIndexSubr),
+		"string-join":                    NewSubroutine(stringJoinSubr),
+		"string-length":                  NewSubroutine(stringLengthSubr),
+		"string-map":                     NewSubroutine(stringMapSubr),
+		"string-ref":                     NewSubroutine(stringRefSubr),
+		"string-search-forward":          NewSubroutine(stringSearchForwardSubr),
+		"string-set!":                    NewSubroutine(stringSetSubr),
+		"string-split":                   NewSubroutine(stringSplitSubr),
+		"string-upcase":                  NewSubroutine(stringUpcaseSubr),
+		"string<?":                       NewSubroutine(stringLessThanSubr),
+		"string<=?":                      NewSubroutine(stringLessEqualSubr),
+		"string=?":                       NewSubroutine(stringEqualSubr),
+		"string>?":                       NewSubroutine(stringGreaterThanSubr),
+		"string>=?":                      NewSubroutine(stringGreaterEqualSubr),
+		"substring":                      NewSubroutine(substringSubr),
+		"symbol?":                        NewSubroutine(isSymbolSubr),
+		"symbol->string":                 NewSubroutine(symbolToStringSubr),
+		"tan":                            NewSubroutine(tanSubr),
+		"truncate":                       NewSubroutine(truncateSubr),
+		"truncate-quotient":              NewSubroutine(quotientSubr),
+		"truncate-remainder":             NewSubroutine(remainderSubr),
+		"truncate/":                      NewSubroutine(truncateDivideSubr),
+		"values":                         NewSubroutine(valuesSubr),
+		"vector":                         NewSubroutine(vectorSubr),
+		"vector?":                        NewSubroutine(isVectorSubr),
+		"vector->list":                   NewSubroutine(vectorToListSubr),
+		"vector-append":                  NewSubroutine(vectorAppendSubr),
+		"vector-copy":                    NewSubroutine(vectorCopySubr),
+		"vector-fill!":                   NewSubroutine(vectorFillSubr),
+		"vector-for-each":                NewSubroutine(vectorForEachSubr),
+		"vector-length":                  NewSubroutine(vectorLengthSubr),
+		"vector-map":                     NewSubroutine(vectorMapSubr),
+		"vector-ref":                     NewSubroutine(vectorRefSubr),
+		"vector-set!":                    NewSubroutine(vectorSetSubr),
+		"with-exception-handler":         NewSubroutine(withExceptionHandlerSubr),
+		"with-input-from-file":           NewSubroutine(withInputFromFileSubr),
+		"with-output-to-file":            NewSubroutine(withOutputToFileSubr),
+		"with-output-to-string":          NewSubroutine(withOutputToStringSubr),
+		"write":                          NewSubroutine(writeSubr),
+		"write-char":                     NewSubroutine(writeCharSubr),
+		"write-string":                   NewSubroutine(writeStringSubr),
+		"zero?":                          NewSubroutine(isZeroSubr),
 	}
 )
 
-func carSubr(s *Subroutine, arguments Object) Object {
+func callWithCurrentContinuationSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 1)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
+	receiver := arguments.(*Pair).ElementAt(0)
+	assertProcedure(receiver)
+	return NewContinuation(false).call(receiver, environment)
+}
+
+// Escape continuation is available only while call/ec is on the stack,
+// and it is cheaper than call/cc because its frames are never captured.
+func callWithEscapeContinuationSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	receiver := arguments.(*Pair).ElementAt(0)
+	assertProcedure(receiver)
+	return NewContinuation(true).call(receiver, environment)
+}
+
+func callWithValuesSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertProcedure(objects[0])
+	assertProcedure(objects[1])
+	return evalThen(func(environment *Environment) Object {
+		return applyProcedure(objects[0], []Object{}, environment)
+	}, environment, func(values Object, environment *Environment) Object {
+		return applyTail(objects[1], valuesOf(values), environment)
+	})
+}
+
+func carSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
 	assertObjectType(object, "pair")
 	return object.(*Pair).Car
 }
 
-func cdrSubr(s *Subroutine, arguments Object) Object {
+func cdrSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 1)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
+	object := arguments.(*Pair).ElementAt(0)
 	assertObjectType(object, "pair")
 	return object.(*Pair).Cdr
 }
 
-func consSubr(s *Subroutine, arguments Object) Object {
+func consSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 2)
-	objects := evaledObjects(arguments.(*Pair).Elements())
+	objects := arguments.(*Pair).Elements()
 
 	return &Pair{
 		ObjectBase: ObjectBase{parent: arguments.Parent()},
@@ -80,68 +301,128 @@ func consSubr(s *Subroutine, arguments Object) Object {
 	}
 }
 
-func divideSubr(s *Subroutine, arguments Object) Object {
-	assertListMinimum(arguments, 1)
-
-	numbers := evaledObjects(arguments.(*Pair).Elements())
-	assertObjectsType(numbers, "number")
+// Returns a list of frames from the innermost one, which is current-backtrace itself.
+// Each frame is a list of procedure name, call-site and a list of arguments.
+func currentBacktraceSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 0)
 
-	quotient := numbers[0].(*Number).value
-	for _, number := range numbers[1:] {
-		quotient /= number.(*Number).value
+	frames := []Object{}
+	for _, frame := range currentBacktrace(environment) {
+		var location Object = NewBoolean(false)
+		if frame.Location != nil {
+			location = NewString(frame.Location.String())
+		}
+		frames = append(frames, NewList(nil, NewSymbol(frame.Name()), location, NewList(nil, frame.Arguments...)))
 	}
-	return NewNumber(quotient)
+	return NewList(nil, frames...)
 }
 
-func dumpSubr(s *Subroutine, arguments Object) Object {
-	object := arguments.(*Pair).ElementAt(0).Eval()
+func divideSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 1)
+	return s.foldNumbers(arguments, &division, 1)
+}
+
+func dumpSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	object := arguments.(*Pair).ElementAt(0)
 	fmt.Printf("%d\n", object)
 	return undef
 }
 
-func equalSubr(s *Subroutine, arguments Object) Object {
-	return s.compareNumbers(arguments, func(a, b int) bool { return a == b })
+func equalSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareNumbers(arguments, func(ordering int) bool { return ordering == 0 })
+}
+
+func errorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 1)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	return raise(NewErrorObject(errorKindUser, objects[0].(*String).text(), objects[1:]), false, environment)
+}
+
+func errorObjectIrritantsSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "error-object")
+	return NewList(arguments.Parent(), object.(*ErrorObject).irritants...)
+}
+
+// Kind is a symbol to classify error objects, such as error or unbound-variable.
+func errorObjectKindSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "error-object")
+	return NewSymbol(object.(*ErrorObject).kind)
+}
+
+func errorObjectMessageSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "error-object")
+	return NewString(object.(*ErrorObject).message)
 }
 
-func exitSubr(s *Subroutine, arguments Object) Object {
+func exitSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	os.Exit(0)
 	return undef
 }
 
-func greaterThanSubr(s *Subroutine, arguments Object) Object {
-	return s.compareNumbers(arguments, func(a, b int) bool { return a > b })
+func greaterThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareReals(arguments, func(ordering int) bool { return ordering > 0 })
 }
 
-func greaterEqualSubr(s *Subroutine, arguments Object) Object {
-	return s.compareNumbers(arguments, func(a, b int) bool { return a >= b })
+func greaterEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareReals(arguments, func(ordering int) bool { return ordering >= 0 })
 }
 
-func lengthSubr(s *Subroutine, arguments Object) Object {
+func lengthSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 1)
 
-	list := arguments.(*Pair).ElementAt(0).Eval()
+	list := arguments.(*Pair).ElementAt(0)
 	assertListMinimum(list, 0)
 
 	return NewNumber(list.(*Pair).ListLength())
 }
 
-func lessEqualSubr(s *Subroutine, arguments Object) Object {
-	return s.compareNumbers(arguments, func(a, b int) bool { return a <= b })
+func lessEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareReals(arguments, func(ordering int) bool { return ordering <= 0 })
+}
+
+func lessThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareReals(arguments, func(ordering int) bool { return ordering < 0 })
+}
+
+func listSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 0)
+	return NewList(arguments.Parent(), arguments.(*Pair).Elements()...)
 }
 
-func lessThanSubr(s *Subroutine, arguments Object) Object {
-	return s.compareNumbers(arguments, func(a, b int) bool { return a < b })
+func macroexpandSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	form := arguments.(*Pair).ElementAt(0)
+	for expanded := true; expanded; {
+		form, expanded = expandMacro(form, environment)
+	}
+	return form
 }
 
-func listSubr(s *Subroutine, arguments Object) Object {
-	return arguments
+func macroexpand1Subr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	form := arguments.(*Pair).ElementAt(0)
+	expansion, _ := expandMacro(form, environment)
+	return expansion
 }
 
-func memqSubr(s *Subroutine, arguments Object) Object {
+func memqSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 2)
 
-	searchObject := arguments.(*Pair).ElementAt(0).Eval()
-	list := arguments.(*Pair).ElementAt(1).Eval()
+	searchObject := arguments.(*Pair).ElementAt(0)
+	list := arguments.(*Pair).ElementAt(1)
 
 	for {
 		switch list.(type) {
@@ -160,48 +441,31 @@ func memqSubr(s *Subroutine, arguments Object) Object {
 	return NewBoolean(false)
 }
 
-func minusSubr(s *Subroutine, arguments Object) Object {
+func minusSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListMinimum(arguments, 1)
-
-	numbers := evaledObjects(arguments.(*Pair).Elements())
-	assertObjectsType(numbers, "number")
-
-	difference := numbers[0].(*Number).value
-	for _, number := range numbers[1:] {
-		difference -= number.(*Number).value
-	}
-	return NewNumber(difference)
+	return s.foldNumbers(arguments, &subtraction, 0)
 }
 
-func multiplySubr(s *Subroutine, arguments Object) Object {
-	assertListMinimum(arguments, 0)
-
-	numbers := evaledObjects(arguments.(*Pair).Elements())
-	assertObjectsType(numbers, "number")
-
-	product := 1
-	for _, number := range numbers {
-		product *= number.(*Number).value
-	}
-	return NewNumber(product)
+func multiplySubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.foldNumbers(arguments, &multiplication, 1)
 }
 
-func lastSubr(s *Subroutine, arguments Object) Object {
+func lastSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 1)
 
-	list := arguments.(*Pair).ElementAt(0).Eval()
+	list := arguments.(*Pair).ElementAt(0)
 	if !list.isPair() {
-		runtimeError("pair required: %s", list)
+		raiseError(errorKindWrongType, "pair required: %s", list)
 	}
 	assertListMinimum(list, 1)
 
 	elements := list.(*Pair).Elements()
-	return elements[len(elements)-1].Eval()
+	return elements[len(elements)-1]
 }
 
-func appendSubr(s *Subroutine, arguments Object) Object {
+func appendSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListMinimum(arguments, 0)
-	elements := evaledObjects(arguments.(*Pair).Elements())
+	elements := arguments.(*Pair).Elements()
 
 	appendedList := NewPair(arguments)
 	for _, element := range elements {
@@ -211,169 +475,1590 @@ func appendSubr(s *Subroutine, arguments Object) Object {
 	return appendedList
 }
 
-func numberToStringSubr(s *Subroutine, arguments Object) Object {
-	assertListEqual(arguments, 1)
+func numberToStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
-	assertObjectType(object, "number")
-	return NewString(object.(*Number).value)
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "number")
+	return NewString(objects[0].(*Number).text(radixArgument(objects[1:])))
 }
 
-func isBooleanSubr(s *Subroutine, arguments Object) Object {
+func isBooleanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	return s.booleanByFunc(arguments, func(object Object) bool { return object.isBoolean() })
 }
 
-func isEqSubr(s *Subroutine, arguments Object) Object {
+func isErrorObjectSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		_, ok := object.(*ErrorObject)
+		return ok
+	})
+}
+
+func isEqSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 2)
 
-	objects := evaledObjects(arguments.(*Pair).Elements())
+	objects := arguments.(*Pair).Elements()
 	return NewBoolean(areIdentical(objects[0], objects[1]))
 }
 
-func isEqualSubr(s *Subroutine, arguments Object) Object {
+func isEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 2)
 
-	objects := evaledObjects(arguments.(*Pair).Elements())
+	objects := arguments.(*Pair).Elements()
 	return NewBoolean(areEqual(objects[0], objects[1]))
 }
 
-func isListSubr(s *Subroutine, arguments Object) Object {
+func isListSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	return s.booleanByFunc(arguments, func(object Object) bool { return object.isList() })
 }
 
-func isNeqSubr(s *Subroutine, arguments Object) Object {
-	return NewBoolean(!isEqSubr(s, arguments).(*Boolean).value)
+func isNeqSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return NewBoolean(!isEqSubr(s, arguments, environment).(*Boolean).value)
 }
 
-func isNumberSubr(s *Subroutine, arguments Object) Object {
+func isNumberSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	return s.booleanByFunc(arguments, func(object Object) bool { return object.isNumber() })
 }
 
-func isPairSubr(s *Subroutine, arguments Object) Object {
+func isPairSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	return s.booleanByFunc(arguments, func(object Object) bool { return object.isPair() })
 }
 
-func isProcedureSubr(s *Subroutine, arguments Object) Object {
+func isProcedureSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	return s.booleanByFunc(arguments, func(object Object) bool { return object.isProcedure() })
 }
 
-func isSymbolSubr(s *Subroutine, arguments Object) Object {
+func isSymbolSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	return s.booleanByFunc(arguments, func(object Object) bool { return object.isSymbol() })
 }
 
-func isStringSubr(s *Subroutine, arguments Object) Object {
+func isStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	return s.booleanByFunc(arguments, func(object Object) bool { return object.isString() })
 }
 
-func loadSubr(s *Subroutine, arguments Object) Object {
+func loadSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 1)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
+	object := arguments.(*Pair).ElementAt(0)
 	assertObjectType(object, "string")
 
-	buffer, err := ioutil.ReadFile(object.(*String).text)
+	buffer, err := ioutil.ReadFile(object.(*String).text())
 	if err != nil {
-		runtimeError("cannot find \"%s\"", object.(*String).text)
+		runtimeError("cannot find \"%s\"", object.(*String).text())
 		return nil
 	}
 
-	parser := NewParser(string(buffer))
+	parser := NewFileParser(object.(*String).text(), string(buffer))
 	parser.Peek()
-	for _, e := range parser.Parse(arguments.Parent()) {
-		e.Eval()
+	global := environment.global()
+	frame := withEvaluation(global, environment.evaluation)
+	for _, e := range parser.Parse(nil) {
+		e := e
+		evalRoot(func() Object {
+			return compile(e, NewGlobalScope(global))(frame)
+		}, frame.evaluation, true)
 	}
 
 	return NewBoolean(true)
 }
 
-func plusSubr(s *Subroutine, arguments Object) Object {
-	assertListMinimum(arguments, 0)
+func plusSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.foldNumbers(arguments, &addition, 0)
+}
 
-	numbers := evaledObjects(arguments.(*Pair).Elements())
-	assertObjectsType(numbers, "number")
+func printSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
 
-	sum := 0
-	for _, number := range numbers {
-		sum += number.(*Number).value
-	}
-	return NewNumber(sum)
+	objects := arguments.(*Pair).Elements()
+	outputPortArgument(objects[1:], environment).write(displayString(objects[0]) + "\n")
+	return undef
 }
 
-func printSubr(s *Subroutine, arguments Object) Object {
-	assertListEqual(arguments, 1) // TODO: accept output port
+func raiseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+	return raise(arguments.(*Pair).ElementAt(0), false, environment)
+}
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
-	if object.isString() {
-		fmt.Printf("%s\n", object.(*String).text)
-	} else {
-		fmt.Printf("%s\n", object)
-	}
-	return undef
+func raiseContinuableSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+	return raise(arguments.(*Pair).ElementAt(0), true, environment)
 }
 
-func setCarSubr(s *Subroutine, arguments Object) Object {
+func setCarSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 2)
 
-	object := arguments.(*Pair).ElementAt(1).Eval()
-	pair := arguments.(*Pair).ElementAt(0).Eval()
+	object := arguments.(*Pair).ElementAt(1)
+	pair := arguments.(*Pair).ElementAt(0)
 	assertObjectType(pair, "pair")
 
 	pair.(*Pair).Car = object
 	return undef
 }
 
-func setCdrSubr(s *Subroutine, arguments Object) Object {
+func setCdrSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 2)
 
-	object := arguments.(*Pair).ElementAt(1).Eval()
-	pair := arguments.(*Pair).ElementAt(0).Eval()
+	object := arguments.(*Pair).ElementAt(1)
+	pair := arguments.(*Pair).ElementAt(0)
 	assertObjectType(pair, "pair")
 
 	pair.(*Pair).Cdr = object
 	return undef
 }
 
-func stringAppendSubr(s *Subroutine, arguments Object) Object {
+func stringAppendSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListMinimum(arguments, 0)
 
-	stringObjects := evaledObjects(arguments.(*Pair).Elements())
+	stringObjects := arguments.(*Pair).Elements()
 	assertObjectsType(stringObjects, "string")
 
 	texts := []string{}
 	for _, stringObject := range stringObjects {
-		texts = append(texts, stringObject.(*String).text)
+		texts = append(texts, stringObject.(*String).text())
 	}
 	return NewString(strings.Join(texts, ""))
 }
 
-func stringToNumberSubr(s *Subroutine, arguments Object) Object {
-	assertListEqual(arguments, 1)
+// Returns #f if the string is not a number.
+func stringToNumberSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
-	assertObjectType(object, "string")
-	return NewNumber(object.(*String).text)
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	if number := parseNumber(objects[0].(*String).text(), radixArgument(objects[1:])); number != nil {
+		return number
+	}
+	return NewBoolean(false)
+}
+
+// Returns a radix given as an optional argument, or 10.
+func radixArgument(objects []Object) int {
+	if len(objects) == 0 {
+		return 10
+	}
+	for _, radix := range []int{2, 8, 10, 16} {
+		if areIdentical(objects[0], NewNumber(radix)) {
+			return radix
+		}
+	}
+	typeError("radix must be 2, 8, 10 or 16, but got %s", objects[0])
+	return 10
 }
 
-func symbolToStringSubr(s *Subroutine, arguments Object) Object {
+func symbolToStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 1)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
+	object := arguments.(*Pair).ElementAt(0)
 	assertObjectType(object, "symbol")
 	return NewString(object.(*Symbol).identifier)
 }
 
-func stringToSymbolSubr(s *Subroutine, arguments Object) Object {
+func stringToSymbolSubr(s *Subroutine, arguments Object, environment *Environment) Object {
 	assertListEqual(arguments, 1)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
+	object := arguments.(*Pair).ElementAt(0)
 	assertObjectType(object, "string")
-	return NewSymbol(object.(*String).text)
+	return NewSymbol(object.(*String).text())
 }
 
-func writeSubr(s *Subroutine, arguments Object) Object {
-	assertListEqual(arguments, 1) // TODO: accept output port
+func valuesSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 0)
+	return NewValues(arguments.(*Pair).Elements())
+}
+
+func withExceptionHandlerSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
-	fmt.Printf("%s", object)
+	objects := arguments.(*Pair).Elements()
+	assertProcedure(objects[0])
+	assertProcedure(objects[1])
+	return withExceptionHandler(objects[0], objects[1], environment)
+}
+
+func writeSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := arguments.(*Pair).Elements()
+	outputPortArgument(objects[1:], environment).write(objects[0].String())
 	return undef
 }
+
+func angleSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	if object.(*Number).isExact() && object.(*Number).isReal() {
+		if sign, _ := compareNumbers(object.(*Number), NewNumber(0)); sign >= 0 {
+			return NewNumber(0)
+		}
+	}
+	return NewNumber(cmplx.Phase(toComplex(object.(*Number).value)))
+}
+
+func exactSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	return object.(*Number).exact()
+}
+
+func imagPartSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	if object.(*Number).isReal() {
+		return NewNumber(0)
+	}
+	return NewNumber(imag(toComplex(object.(*Number).value)))
+}
+
+func inexactSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	return object.(*Number).inexact()
+}
+
+func isComplexSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		return object.isNumber()
+	})
+}
+
+func isExactIntegerSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		return object.isNumber() && object.(*Number).level() == integerLevel
+	})
+}
+
+func isExactSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	return NewBoolean(object.(*Number).isExact())
+}
+
+func isInexactSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	return NewBoolean(!object.(*Number).isExact())
+}
+
+func isIntegerSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		return object.isNumber() && object.(*Number).isInteger()
+	})
+}
+
+func isRationalSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		return object.isNumber() && object.(*Number).isRational()
+	})
+}
+
+func isRealSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		return object.isNumber() && object.(*Number).isReal()
+	})
+}
+
+func magnitudeSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	if object.(*Number).isReal() {
+		if sign, _ := compareNumbers(object.(*Number), NewNumber(0)); sign < 0 {
+			return subtraction.apply(NewNumber(0), object.(*Number))
+		}
+		return object
+	}
+	return NewNumber(cmplx.Abs(toComplex(object.(*Number).value)))
+}
+
+func makePolarSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertRealNumbers(objects)
+	return NewNumber(complex128FromPolar(toFloat(objects[0].(*Number).value), toFloat(objects[1].(*Number).value)))
+}
+
+func makeRectangularSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertRealNumbers(objects)
+	if objects[1].(*Number).isExact() && areEqvNumbers(objects[1].(*Number), NewNumber(0)) {
+		return objects[0]
+	}
+	return NewNumber(complex(toFloat(objects[0].(*Number).value), toFloat(objects[1].(*Number).value)))
+}
+
+func realPartSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	if object.(*Number).isReal() {
+		return object
+	}
+	return NewNumber(real(toComplex(object.(*Number).value)))
+}
+
+func absSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		return number.abs()
+	})
+}
+
+func acosSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return number.transcend(math.Acos, cmplx.Acos, func(x float64) bool { return x >= -1 && x <= 1 })
+	})
+}
+
+func asinSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return number.transcend(math.Asin, cmplx.Asin, func(x float64) bool { return x >= -1 && x <= 1 })
+	})
+}
+
+// Returns the arctangent of y, or the angle of point (x, y) if x is given.
+func atanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := arguments.(*Pair).Elements()
+	if len(objects) == 1 {
+		assertObjectType(objects[0], "number")
+		return objects[0].(*Number).transcend(math.Atan, cmplx.Atan, nil)
+	}
+	assertRealNumbers(objects)
+	return NewNumber(math.Atan2(toFloat(objects[0].(*Number).value), toFloat(objects[1].(*Number).value)))
+}
+
+func ceilingSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		return number.round(roundCeiling)
+	})
+}
+
+func cosSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return number.transcend(math.Cos, cmplx.Cos, nil)
+	})
+}
+
+func denominatorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		if number.isExact() {
+			return NewNumber(new(big.Int).Set(toRat(number.value).Denom()))
+		}
+		return NewNumber(toRat(number.exact().value).Denom()).inexact()
+	})
+}
+
+// Returns s and k - s^2, where s is the largest integer whose square is not greater than k.
+func exactIntegerSqrtSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	if object.(*Number).level() != integerLevel || object.(*Number).sign() < 0 {
+		typeError("exact non-negative integer required, but got %s", object)
+	}
+
+	k := toBigInt(object.(*Number).value)
+	root := new(big.Int).Sqrt(k)
+	rest := new(big.Int).Sub(k, new(big.Int).Mul(root, root))
+	return NewValues([]Object{NewNumber(root), NewNumber(rest)})
+}
+
+func expSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return number.transcend(math.Exp, cmplx.Exp, nil)
+	})
+}
+
+func exptSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	numbers := arguments.(*Pair).Elements()
+	assertObjectsType(numbers, "number")
+	return expt(numbers[0].(*Number), numbers[1].(*Number))
+}
+
+func floorDivideSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.divideByFunc(arguments, true, func(quotient, remainder *Number) Object {
+		return NewValues([]Object{quotient, remainder})
+	})
+}
+
+func floorQuotientSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.divideByFunc(arguments, true, func(quotient, remainder *Number) Object {
+		return quotient
+	})
+}
+
+func floorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		return number.round(roundFloor)
+	})
+}
+
+func gcdSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 0)
+
+	numbers := arguments.(*Pair).Elements()
+	assertObjectsType(numbers, "number")
+
+	result := NewNumber(0)
+	for _, number := range numbers {
+		result = gcdNumbers(result, number.(*Number))
+	}
+	return result
+}
+
+func isEvenSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		assertInteger(number)
+		return NewBoolean(!number.isOdd())
+	})
+}
+
+func isFiniteSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return NewBoolean(!cmplx.IsInf(toComplex(number.value)) && !cmplx.IsNaN(toComplex(number.value)))
+	})
+}
+
+func isInfiniteSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return NewBoolean(cmplx.IsInf(toComplex(number.value)))
+	})
+}
+
+func isNanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return NewBoolean(cmplx.IsNaN(toComplex(number.value)))
+	})
+}
+
+func isNegativeSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		return NewBoolean(number.sign() < 0)
+	})
+}
+
+func isOddSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		assertInteger(number)
+		return NewBoolean(number.isOdd())
+	})
+}
+
+func isPositiveSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		return NewBoolean(number.sign() > 0)
+	})
+}
+
+func isZeroSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return NewBoolean(number.isZero())
+	})
+}
+
+func lcmSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 0)
+
+	numbers := arguments.(*Pair).Elements()
+	assertObjectsType(numbers, "number")
+
+	result := NewNumber(1)
+	for _, object := range numbers {
+		number := object.(*Number)
+		product := multiplication.apply(result, number).abs()
+		if divisor := gcdNumbers(result, number); divisor.isZero() {
+			result = product
+		} else {
+			result, _ = divideIntegers(product, divisor, false)
+		}
+	}
+	return result
+}
+
+// Returns the natural logarithm of z, or the logarithm of z to the base if it is given.
+func logSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	numbers := arguments.(*Pair).Elements()
+	assertObjectsType(numbers, "number")
+
+	logarithms := []*Number{}
+	for _, number := range numbers {
+		logarithm := number.(*Number).transcend(math.Log, cmplx.Log, func(x float64) bool { return x >= 0 })
+		logarithms = append(logarithms, logarithm)
+	}
+	if len(logarithms) == 1 {
+		return logarithms[0]
+	}
+	return division.apply(logarithms[0], logarithms[1])
+}
+
+func maxSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.selectNumber(arguments, func(ordering int) bool { return ordering > 0 })
+}
+
+func minSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.selectNumber(arguments, func(ordering int) bool { return ordering < 0 })
+}
+
+func moduloSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.divideByFunc(arguments, true, func(quotient, remainder *Number) Object {
+		return remainder
+	})
+}
+
+func numeratorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		if number.isExact() {
+			return NewNumber(new(big.Int).Set(toRat(number.value).Num()))
+		}
+		return NewNumber(toRat(number.exact().value).Num()).inexact()
+	})
+}
+
+func quotientSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.divideByFunc(arguments, false, func(quotient, remainder *Number) Object {
+		return quotient
+	})
+}
+
+func remainderSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.divideByFunc(arguments, false, func(quotient, remainder *Number) Object {
+		return remainder
+	})
+}
+
+func roundSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		return number.round(roundNearest)
+	})
+}
+
+func sinSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return number.transcend(math.Sin, cmplx.Sin, nil)
+	})
+}
+
+func sqrtSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return number.sqrt()
+	})
+}
+
+func squareSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return multiplication.apply(number, number)
+	})
+}
+
+func tanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.numberByFunc(arguments, func(number *Number) Object {
+		return number.transcend(math.Tan, cmplx.Tan, nil)
+	})
+}
+
+func truncateDivideSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.divideByFunc(arguments, false, func(quotient, remainder *Number) Object {
+		return NewValues([]Object{quotient, remainder})
+	})
+}
+
+func truncateSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.realByFunc(arguments, func(number *Number) Object {
+		return number.round(roundTruncate)
+	})
+}
+
+func charCiEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, true, func(difference int) bool { return difference == 0 })
+}
+
+func charCiGreaterEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, true, func(difference int) bool { return difference >= 0 })
+}
+
+func charCiGreaterThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, true, func(difference int) bool { return difference > 0 })
+}
+
+func charCiLessEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, true, func(difference int) bool { return difference <= 0 })
+}
+
+func charCiLessThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, true, func(difference int) bool { return difference < 0 })
+}
+
+func charDowncaseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewChar(unicode.ToLower(char))
+	})
+}
+
+func charEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, false, func(difference int) bool { return difference == 0 })
+}
+
+func charFoldcaseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewChar(unicode.ToLower(char))
+	})
+}
+
+func charGreaterEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, false, func(difference int) bool { return difference >= 0 })
+}
+
+func charGreaterThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, false, func(difference int) bool { return difference > 0 })
+}
+
+func charLessEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, false, func(difference int) bool { return difference <= 0 })
+}
+
+func charLessThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareChars(arguments, false, func(difference int) bool { return difference < 0 })
+}
+
+func charToIntegerSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewNumber(int(char))
+	})
+}
+
+func charUpcaseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewChar(unicode.ToUpper(char))
+	})
+}
+
+// Returns the digit value of a numeric character, or #f.
+// Decimal digits of Unicode are contiguous from zero, so that the value is
+// the number of preceding digits.
+func digitValueSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		if !unicode.IsDigit(char) {
+			return NewBoolean(false)
+		}
+		value := 0
+		for unicode.IsDigit(char - rune(value) - 1) {
+			value++
+		}
+		return NewNumber(value % 10)
+	})
+}
+
+func displaySubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := arguments.(*Pair).Elements()
+	outputPortArgument(objects[1:], environment).write(displayString(objects[0]))
+	return undef
+}
+
+func integerToCharSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	value, ok := object.(*Number).value.(int)
+	if !ok || value < 0 || value > unicode.MaxRune || (value >= 0xd800 && value <= 0xdfff) {
+		typeError("code point required, but got %s", object)
+	}
+	return NewChar(rune(value))
+}
+
+func isCharAlphabeticSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewBoolean(unicode.IsLetter(char))
+	})
+}
+
+func isCharLowerCaseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewBoolean(unicode.IsLower(char))
+	})
+}
+
+func isCharNumericSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewBoolean(unicode.IsDigit(char))
+	})
+}
+
+func isCharSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool { return object.isChar() })
+}
+
+func isCharUpperCaseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewBoolean(unicode.IsUpper(char))
+	})
+}
+
+func isCharWhitespaceSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.charByFunc(arguments, func(char rune) Object {
+		return NewBoolean(unicode.IsSpace(char))
+	})
+}
+
+func listToStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	list := arguments.(*Pair).ElementAt(0)
+	assertListMinimum(list, 0)
+	return stringSubr(s, list, environment)
+}
+
+func makeStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := arguments.(*Pair).Elements()
+	length := assertIndex(objects[0], 0, math.MaxInt32)
+	char := ' '
+	if len(objects) > 1 {
+		assertObjectType(objects[1], "char")
+		char = objects[1].(*Char).value
+	}
+	return NewString(strings.Repeat(string(char), length))
+}
+
+func stringCiEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, true, func(ordering int) bool { return ordering == 0 })
+}
+
+func stringCiGreaterEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, true, func(ordering int) bool { return ordering >= 0 })
+}
+
+func stringCiGreaterThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, true, func(ordering int) bool { return ordering > 0 })
+}
+
+func stringCiLessEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, true, func(ordering int) bool { return ordering <= 0 })
+}
+
+func stringCiLessThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, true, func(ordering int) bool { return ordering < 0 })
+}
+
+// Returns the index of the first occurrence of pattern in string, or #f.
+func stringContainsSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectsType(objects, "string")
+	return searchString(objects[0].(*String).runes, objects[1].(*String).text(), 0)
+}
+
+func stringCopySubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	runes := objects[0].(*String).runes
+	start, end := rangeArguments(objects[1:], len(runes))
+	return NewString(string(runes[start:end]))
+}
+
+func stringDowncaseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "string")
+	return NewString(strings.ToLower(object.(*String).text()))
+}
+
+func stringEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, false, func(ordering int) bool { return ordering == 0 })
+}
+
+func stringFillSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 2, 4)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	assertObjectType(objects[1], "char")
+
+	runes := objects[0].(*String).runes
+	start, end := rangeArguments(objects[2:], len(runes))
+	for index := start; index < end; index++ {
+		runes[index] = objects[1].(*Char).value
+	}
+	return undef
+}
+
+func stringFoldcaseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "string")
+	return NewString(strings.ToLower(object.(*String).text()))
+}
+
+func stringForEachSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertProcedure(objects[0])
+	return s.applyEach(objects[0], stringArgumentLists(objects[1:]), environment, func([]Object, *Environment) Object {
+		return undef
+	})
+}
+
+func stringGreaterEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, false, func(ordering int) bool { return ordering >= 0 })
+}
+
+func stringGreaterThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, false, func(ordering int) bool { return ordering > 0 })
+}
+
+// Returns the index of the first character which matches a character or a predicate, or #f.
+func stringIndexSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 2, 4)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	runes := objects[0].(*String).runes
+	start, end := rangeArguments(objects[2:], len(runes))
+	match := charMatcher(objects[1], environment)
+
+	for index := start; index < end; index++ {
+		if match(runes[index]) {
+			return NewNumber(index)
+		}
+	}
+	return NewBoolean(false)
+}
+
+// Join a list of strings with a delimiter, which is a space by default.
+func stringJoinSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertListMinimum(objects[0], 0)
+	strs := objects[0].(*Pair).Elements()
+	assertObjectsType(strs, "string")
+
+	delimiter := " "
+	if len(objects) > 1 {
+		assertObjectType(objects[1], "string")
+		delimiter = objects[1].(*String).text()
+	}
+
+	texts := []string{}
+	for _, str := range strs {
+		texts = append(texts, str.(*String).text())
+	}
+	return NewString(strings.Join(texts, delimiter))
+}
+
+func stringLengthSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "string")
+	return NewNumber(len(object.(*String).runes))
+}
+
+func stringLessEqualSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, false, func(ordering int) bool { return ordering <= 0 })
+}
+
+func stringLessThanSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.compareStrings(arguments, false, func(ordering int) bool { return ordering < 0 })
+}
+
+func stringMapSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertProcedure(objects[0])
+	return s.applyEach(objects[0], stringArgumentLists(objects[1:]), environment, func(chars []Object, environment *Environment) Object {
+		return stringSubr(s, NewList(nil, chars...), environment)
+	})
+}
+
+func stringRefSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	runes := objects[0].(*String).runes
+	return NewChar(runes[assertIndex(objects[1], 0, len(runes)-1)])
+}
+
+// Returns the index of the first occurrence of pattern in string from start, or #f.
+func stringSearchForwardSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 2, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectsType(objects[:2], "string")
+	pattern, runes := objects[0].(*String).text(), objects[1].(*String).runes
+	start, _ := rangeArguments(objects[2:], len(runes))
+	return searchString(runes, pattern, start)
+}
+
+func stringSetSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	assertObjectType(objects[2], "char")
+
+	runes := objects[0].(*String).runes
+	runes[assertIndex(objects[1], 0, len(runes)-1)] = objects[2].(*Char).value
+	return undef
+}
+
+// Split string by a delimiter, which is a character, a string or a predicate.
+func stringSplitSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	text := objects[0].(*String).text()
+
+	var fields []string
+	if delimiter, ok := objects[1].(*String); ok {
+		if delimiter.text() == "" {
+			runtimeError("empty delimiter for string-split")
+		}
+		fields = strings.Split(text, delimiter.text())
+	} else {
+		fields = splitFunc(text, charMatcher(objects[1], environment))
+	}
+
+	strs := []Object{}
+	for _, field := range fields {
+		strs = append(strs, NewString(field))
+	}
+	return NewList(nil, strs...)
+}
+
+func stringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 0)
+
+	chars := arguments.(*Pair).Elements()
+	assertObjectsType(chars, "char")
+
+	runes := []rune{}
+	for _, char := range chars {
+		runes = append(runes, char.(*Char).value)
+	}
+	return NewString(string(runes))
+}
+
+func stringToListSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	runes := objects[0].(*String).runes
+	start, end := rangeArguments(objects[1:], len(runes))
+
+	chars := []Object{}
+	for _, char := range runes[start:end] {
+		chars = append(chars, NewChar(char))
+	}
+	return NewList(nil, chars...)
+}
+
+func stringUpcaseSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "string")
+	return NewString(strings.ToUpper(object.(*String).text()))
+}
+
+func substringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 2, 3)
+	return stringCopySubr(s, arguments, environment)
+}
+
+func isVectorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	return NewBoolean(object.isVector())
+}
+
+func listToVectorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	list := arguments.(*Pair).ElementAt(0)
+	assertListMinimum(list, 0)
+	return NewVector(list.(*Pair).Elements())
+}
+
+func makeVectorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := arguments.(*Pair).Elements()
+	length := assertIndex(objects[0], 0, math.MaxInt32)
+	var fill Object = undef
+	if len(objects) > 1 {
+		fill = objects[1]
+	}
+
+	elements := make([]Object, length)
+	for index := range elements {
+		elements[index] = fill
+	}
+	return NewVector(elements)
+}
+
+func vectorAppendSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 0)
+
+	vectors := arguments.(*Pair).Elements()
+	assertObjectsType(vectors, "vector")
+
+	elements := []Object{}
+	for _, vector := range vectors {
+		elements = append(elements, vector.(*Vector).elements...)
+	}
+	return NewVector(elements)
+}
+
+func vectorCopySubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	start, end := rangeArguments(objects[1:], len(elements))
+	return NewVector(append([]Object{}, elements[start:end]...))
+}
+
+func vectorFillSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 2, 4)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "vector")
+
+	elements := objects[0].(*Vector).elements
+	start, end := rangeArguments(objects[2:], len(elements))
+	for index := start; index < end; index++ {
+		elements[index] = objects[1]
+	}
+	return undef
+}
+
+func vectorForEachSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertProcedure(objects[0])
+	return s.applyEach(objects[0], vectorArgumentLists(objects[1:]), environment, func([]Object, *Environment) Object {
+		return undef
+	})
+}
+
+func vectorLengthSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "vector")
+	return NewNumber(len(object.(*Vector).elements))
+}
+
+func vectorMapSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertProcedure(objects[0])
+	return s.applyEach(objects[0], vectorArgumentLists(objects[1:]), environment, func(results []Object, environment *Environment) Object {
+		return NewVector(results)
+	})
+}
+
+func vectorRefSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	return elements[assertIndex(objects[1], 0, len(elements)-1)]
+}
+
+func vectorSetSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	elements[assertIndex(objects[1], 0, len(elements)-1)] = objects[2]
+	return undef
+}
+
+func vectorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListMinimum(arguments, 0)
+	return NewVector(arguments.(*Pair).Elements())
+}
+
+func vectorToListSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "vector")
+	elements := objects[0].(*Vector).elements
+	start, end := rangeArguments(objects[1:], len(elements))
+	return NewList(nil, elements[start:end]...)
+}
+
+func hashTableContainsSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "hash-table")
+	return NewBoolean(objects[0].(*HashTable).lookup(objects[1]) != nil)
+}
+
+func hashTableCountSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "hash-table")
+	return NewNumber(object.(*HashTable).size)
+}
+
+func hashTableDeleteSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "hash-table")
+	objects[0].(*HashTable).delete(objects[1])
+	return undef
+}
+
+func hashTableKeysSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "hash-table")
+
+	keys := []Object{}
+	for _, entry := range object.(*HashTable).entries() {
+		keys = append(keys, entry.key)
+	}
+	return NewList(nil, keys...)
+}
+
+// Returns the value for key. If it is not found, failure is called instead.
+// If success is given, it is called with the value.
+func hashTableRefSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 2, 4)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "hash-table")
+	for _, procedure := range objects[2:] {
+		assertProcedure(procedure)
+	}
+
+	entry := objects[0].(*HashTable).lookup(objects[1])
+	if entry == nil {
+		if len(objects) < 3 {
+			return runtimeError("hash table doesn't have an entry for key: %s", objects[1])
+		}
+		return applyTail(objects[2], []Object{}, environment)
+	} else if len(objects) > 3 {
+		return applyTail(objects[3], []Object{entry.value}, environment)
+	}
+	return entry.value
+}
+
+func hashTableRefDefaultSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "hash-table")
+	if entry := objects[0].(*HashTable).lookup(objects[1]); entry != nil {
+		return entry.value
+	}
+	return objects[2]
+}
+
+func hashTableSetSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 3)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "hash-table")
+	objects[0].(*HashTable).set(objects[1], objects[2])
+	return undef
+}
+
+func hashTableToAlistSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "hash-table")
+
+	pairs := []Object{}
+	for _, entry := range object.(*HashTable).entries() {
+		pairs = append(pairs, &Pair{Car: entry.key, Cdr: entry.value})
+	}
+	return NewList(nil, pairs...)
+}
+
+// Set the value for key to the result of procedure, which receives the current value.
+// If key is not found, the current value is the result of failure.
+func hashTableUpdateSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 3, 4)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "hash-table")
+	for _, procedure := range objects[2:] {
+		assertProcedure(procedure)
+	}
+
+	table, key, procedure := objects[0].(*HashTable), objects[1], objects[2]
+	update := func(values []Object, environment *Environment) Object {
+		return s.applyEach(procedure, [][]Object{values}, environment, func(values []Object, environment *Environment) Object {
+			table.set(key, values[0])
+			return undef
+		})
+	}
+
+	if entry := table.lookup(key); entry != nil {
+		return update([]Object{entry.value}, environment)
+	} else if len(objects) < 4 {
+		return runtimeError("hash table doesn't have an entry for key: %s", key)
+	}
+	return s.applyEach(objects[3], [][]Object{{}}, environment, update)
+}
+
+func hashTableValuesSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "hash-table")
+
+	values := []Object{}
+	for _, entry := range object.(*HashTable).entries() {
+		values = append(values, entry.value)
+	}
+	return NewList(nil, values...)
+}
+
+// Call procedure with each key and value of the hash table.
+func hashTableWalkSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "hash-table")
+	assertProcedure(objects[1])
+
+	argumentLists := [][]Object{}
+	for _, entry := range objects[0].(*HashTable).entries() {
+		argumentLists = append(argumentLists, []Object{entry.key, entry.value})
+	}
+	return s.applyEach(objects[1], argumentLists, environment, func([]Object, *Environment) Object {
+		return undef
+	})
+}
+
+func isHashTableSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	return NewBoolean(object.isHashTable())
+}
+
+// Make a hash table whose keys are compared by eq?, eqv?, equal? or string=?.
+// The default equality is equal?.
+func makeHashTableSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 0, 1)
+
+	equality := hashEqualities["equal?"]
+	if arguments.(*Pair).ListLength() > 0 {
+		object := arguments.(*Pair).ElementAt(0)
+		assertProcedure(object)
+		if object.Bounder() == nil || hashEqualities[identifierName(object.Bounder())] == nil {
+			return runtimeError("unsupported equality for hash table: %s", object)
+		}
+		equality = hashEqualities[identifierName(object.Bounder())]
+	}
+	return NewHashTable(equality)
+}
+
+func callWithInputFileSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	assertProcedure(objects[1])
+	return callWithPort(openInputFile(objects[0].(*String).text()), objects[1], environment)
+}
+
+func callWithOutputFileSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	assertProcedure(objects[1])
+	return callWithPort(openOutputFile(objects[0].(*String).text()), objects[1], environment)
+}
+
+func closePortSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "port")
+	object.(*Port).close()
+	return undef
+}
+
+func currentErrorPortSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 0)
+	return currentErrorPort(environment)
+}
+
+func currentInputPortSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 0)
+	return currentInputPort(environment)
+}
+
+func currentOutputPortSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 0)
+	return currentOutputPort(environment)
+}
+
+func eofObjectSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 0)
+	return eofObject
+}
+
+func flushOutputPortSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 0, 1)
+
+	outputPortArgument(arguments.(*Pair).Elements(), environment).flush()
+	return undef
+}
+
+func isEOFObjectSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool { return object == eofObject })
+}
+
+func isFileErrorSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		errorObject, ok := object.(*ErrorObject)
+		return ok && errorObject.kind == errorKindFile
+	})
+}
+
+func isInputPortSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		port, ok := object.(*Port)
+		return ok && port.isInputPort()
+	})
+}
+
+func isOutputPortSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		port, ok := object.(*Port)
+		return ok && port.isOutputPort()
+	})
+}
+
+func isPortSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	return s.booleanByFunc(arguments, func(object Object) bool {
+		_, ok := object.(*Port)
+		return ok
+	})
+}
+
+func newlineSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 0, 1)
+
+	outputPortArgument(arguments.(*Pair).Elements(), environment).write("\n")
+	return undef
+}
+
+func openInputFileSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "string")
+	return openInputFile(object.(*String).text())
+}
+
+func openOutputFileSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "string")
+	return openOutputFile(object.(*String).text())
+}
+
+func peekCharSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 0, 1)
+
+	if char, ok := inputPortArgument(arguments.(*Pair).Elements(), environment).peekRune(); ok {
+		return NewChar(char)
+	}
+	return eofObject
+}
+
+func readCharSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 0, 1)
+
+	if char, ok := inputPortArgument(arguments.(*Pair).Elements(), environment).readRune(); ok {
+		return NewChar(char)
+	}
+	return eofObject
+}
+
+func readLineSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 0, 1)
+
+	if line, ok := inputPortArgument(arguments.(*Pair).Elements(), environment).readLine(); ok {
+		return NewString(line)
+	}
+	return eofObject
+}
+
+// Read at most k characters, or returns the eof object at the end of input.
+func readStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := arguments.(*Pair).Elements()
+	length := assertIndex(objects[0], 0, math.MaxInt32)
+	port := inputPortArgument(objects[1:], environment)
+
+	runes := []rune{}
+	for len(runes) < length {
+		char, ok := port.readRune()
+		if !ok {
+			break
+		}
+		runes = append(runes, char)
+	}
+	if len(runes) == 0 && length > 0 {
+		return eofObject
+	}
+	return NewString(string(runes))
+}
+
+func withInputFromFileSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	assertProcedure(objects[1])
+	return withPort(openInputFile(objects[0].(*String).text()), objects[1], environment)
+}
+
+func withOutputToFileSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	assertProcedure(objects[1])
+	return withPort(openOutputFile(objects[0].(*String).text()), objects[1], environment)
+}
+
+func writeCharSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 2)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "char")
+	outputPortArgument(objects[1:], environment).write(string(objects[0].(*Char).value))
+	return undef
+}
+
+// Write characters of string from start to end.
+func writeStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 1, 4)
+
+	objects := arguments.(*Pair).Elements()
+	assertObjectType(objects[0], "string")
+	port := outputPortArgument(objects[1:], environment)
+
+	runes := objects[0].(*String).runes
+	start, end := 0, len(runes)
+	if len(objects) > 2 {
+		start, end = rangeArguments(objects[2:], len(runes))
+	}
+	port.write(string(runes[start:end]))
+	return undef
+}
+
+func callWithOutputStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	procedure := arguments.(*Pair).ElementAt(0)
+	assertProcedure(procedure)
+	port := openOutputString()
+	return evalThen(func(environment *Environment) Object {
+		return callWithPort(port, procedure, environment)
+	}, environment, func(_ Object, environment *Environment) Object {
+		return NewString(port.outputString())
+	})
+}
+
+func getOutputStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "port")
+	return NewString(object.(*Port).outputString())
+}
+
+func openInputStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "string")
+	return openInputString(object.(*String).text())
+}
+
+func openOutputStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 0)
+	return openOutputString()
+}
+
+// Call thunk while the current output port is a string port, and returns the string written to it.
+func withOutputToStringSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListEqual(arguments, 1)
+
+	thunk := arguments.(*Pair).ElementAt(0)
+	assertProcedure(thunk)
+	port := openOutputString()
+	return evalThen(func(environment *Environment) Object {
+		return withPort(port, thunk, environment)
+	}, environment, func(_ Object, environment *Environment) Object {
+		return NewString(port.outputString())
+	})
+}
+
+// Read a datum from port, such as a list, a symbol, a number, a string or a vector.
+func readSubr(s *Subroutine, arguments Object, environment *Environment) Object {
+	assertListRange(arguments, 0, 1)
+	return inputPortArgument(arguments.(*Pair).Elements(), environment).readDatum()
+}
@@ -0,0 +1,71 @@
+// Char is a type for scheme character object, which is expressed like #\a,
+// #\space or #\x41.
+
+package scheme
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+var charNames = map[string]rune{
+	"alarm":     '\a',
+	"backspace": '\b',
+	"delete":    0x7f,
+	"escape":    0x1b,
+	"newline":   '\n',
+	"null":      0,
+	"return":    '\r',
+	"space":     ' ',
+	"tab":       '\t',
+}
+
+type Char struct {
+	ObjectBase
+	value rune
+}
+
+func NewChar(value rune, options ...Object) *Char {
+	if len(options) > 0 {
+		return &Char{ObjectBase: ObjectBase{parent: options[0]}, value: value}
+	} else {
+		return &Char{value: value}
+	}
+}
+
+// Parse a character literal such as #\a and #\x41. Returns false if it is malformed.
+func parseChar(token string) (rune, bool) {
+	runes := []rune(token)
+	if len(runes) < 3 || string(runes[:2]) != "#\\" {
+		return 0, false
+	}
+
+	name := string(runes[2:])
+	if len(runes) == 3 {
+		return runes[2], true
+	} else if value, ok := charNames[name]; ok {
+		return value, true
+	} else if name[0] == 'x' {
+		if value, err := strconv.ParseUint(name[1:], 16, 32); err == nil && value <= unicode.MaxRune {
+			return rune(value), true
+		}
+	}
+	return 0, false
+}
+
+func (c *Char) String() string {
+	for name, value := range charNames {
+		if c.value == value {
+			return "#\\" + name
+		}
+	}
+	if !unicode.IsGraphic(c.value) {
+		return fmt.Sprintf("#\\x%x", c.value)
+	}
+	return "#\\" + string(c.value)
+}
+
+func (c *Char) isChar() bool {
+	return true
+}
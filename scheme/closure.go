@@ -1,5 +1,6 @@
 // Closure is an object returned by lambda.
-// It has references for closures scoped when it was generated.
+// It has a reference for the environment where it was generated,
+// and each call creates a new environment frame chained to it.
 
 package scheme
 
@@ -9,20 +10,12 @@ import (
 
 type Closure struct {
 	ObjectBase
-	localBinding Binding
-	function     func(Object) Object
+	environment *Environment
+	function    func([]Object, *evaluation) Object
 }
 
-func NewClosure(parent Object) *Closure {
-	return &Closure{ObjectBase: ObjectBase{parent: parent}, localBinding: make(Binding)}
-}
-
-// Cover the given object with a new closure.
-// Insert this into tree structure between given object and its parent.
-func WrapClosure(wrappedObject Object) *Closure {
-	closure := NewClosure(wrappedObject.Parent())
-	wrappedObject.setParent(closure)
-	return closure
+func NewClosure(environment *Environment) *Closure {
+	return &Closure{ObjectBase: ObjectBase{parent: nil}, environment: environment}
 }
 
 func (c *Closure) String() string {
@@ -32,25 +25,41 @@ func (c *Closure) String() string {
 	return fmt.Sprintf("#<closure %s>", c.Bounder())
 }
 
-func (c *Closure) DefineFunction(s *Syntax, variables, body []Object) {
-	c.function = func(givenArguments Object) Object {
+// Define closure's function which binds arguments to the first slots of
+// a frame for scope and evaluates compiled body in the frame.
+// If variadic is true, the slot next to parameters is bound to a list of rest arguments.
+// The frame belongs to the evaluation of caller.
+func (c *Closure) DefineFunction(parameters int, variadic bool, scope *Scope, body Code) {
+	c.function = func(givenArguments []Object, evaluation *evaluation) Object {
 		// assert given arguments
-		givenElements := s.elementsMinimum(givenArguments, 0)
-		if len(variables) != len(givenElements) {
-			compileError("wrong number of arguments: requires %d, but got %d", len(variables), len(givenElements))
+		if variadic && len(givenArguments) < parameters {
+			defer func() { pend(recover(), evaluation) }()
+			arityError("wrong number of arguments: requires at least %d, but got %d", parameters, len(givenArguments))
+		} else if !variadic && len(givenArguments) != parameters {
+			defer func() { pend(recover(), evaluation) }()
+			arityError("wrong number of arguments: requires %d, but got %d", parameters, len(givenArguments))
 		}
 
-		// define arguments to local scope
-		for index, variable := range variables {
-			c.tryDefine(variable, givenElements[index].Eval())
+		// define arguments to a new frame
+		environment := withEvaluation(c.environment, evaluation)
+		environment.values = make([]Object, scope.size())
+		copy(environment.values, givenArguments[:parameters])
+		if variadic {
+			environment.values[parameters] = NewList(nil, givenArguments[parameters:]...)
 		}
 
-		return evalAll(body)
+		return body(environment)
 	}
 }
 
-func (c *Closure) Invoke(argument Object) Object {
-	return c.function(argument)
+// Call closure with evaluated arguments and returns its result.
+// The call is a root of continuations, since Go's stack of caller cannot be resumed,
+// and it starts a new evaluation.
+func (c *Closure) Call(arguments []Object) Object {
+	environment := newEvaluation(c.environment)
+	return evalRoot(func() Object {
+		return applyProcedure(c, arguments, environment)
+	}, environment.evaluation, true)
 }
 
 func (c *Closure) isClosure() bool {
@@ -60,34 +69,3 @@ func (c *Closure) isClosure() bool {
 func (c *Closure) isProcedure() bool {
 	return true
 }
-
-// This method is for define syntax form.
-// Define a local variable in the most inner closure.
-func (c *Closure) define(identifier string, object Object) {
-	c.localBinding[identifier] = object
-}
-
-// If variable is *Variable, define value.
-func (c *Closure) tryDefine(variable Object, object Object) {
-	if variable.isVariable() {
-		c.localBinding[variable.(*Variable).identifier] = object
-	}
-}
-
-// This method is for set! syntax form.
-// Update most inner scoped closure's binding, otherwise raise error.
-func (c *Closure) set(identifier string, object Object) {
-	if c.localBinding[identifier] == nil {
-		if c.parent == nil {
-			runtimeError("symbol not defined")
-		} else {
-			c.parent.set(identifier, object)
-		}
-	} else {
-		c.localBinding[identifier] = object
-	}
-}
-
-func (c *Closure) binding() Binding {
-	return c.localBinding
-}
@@ -0,0 +1,448 @@
+// Compiler translates an expression of AST into Code, which is a chain of
+// Go closures. Variables are resolved by Scope at compile time, syntax forms
+// and macro uses are expanded at compile time, so that evaluation of Code
+// does not traverse AST nor search variables by name.
+
+package scheme
+
+// Code is a compiled expression which is evaluated in a runtime environment.
+type Code func(*Environment) Object
+
+// Compile an expression into code which returns its value.
+func compile(object Object, scope *Scope) Code {
+	if !object.isApplication() {
+		return compileTail(object, scope)
+	}
+
+	code := compileTail(object, scope)
+	location := object.Location()
+	return func(environment *Environment) Object {
+		enterEval(environment)
+		frame := pushFrame(environment, location)
+		defer func() {
+			leaveEval(environment)
+			popFrame(environment, frame)
+			if isCapturing(environment) {
+				suspend(recover(), forceFrame(environment))
+			}
+		}()
+		defer func() {
+			if isRaising(environment) {
+				handle(recover(), environment)
+			}
+		}()
+		return force(code(environment))
+	}
+}
+
+// Returns a maker of frame which forces a tail call returned by rest of computation.
+func forceFrame(environment *Environment) func() resumption {
+	return func() resumption {
+		return func(object Object) Object {
+			return forcePoint(object, environment)
+		}
+	}
+}
+
+// Force a tail call at an evaluation point, which may be suspended again.
+func forcePoint(object Object, environment *Environment) Object {
+	defer func() {
+		if isCapturing(environment) {
+			suspend(recover(), forceFrame(environment))
+		}
+	}()
+	return force(object)
+}
+
+// Returns code which evaluates code in non-tail position and forces its result.
+func compileForced(code Code) Code {
+	return func(environment *Environment) Object {
+		return forcePoint(evalPoint(code, environment, forceFrame(environment)), environment)
+	}
+}
+
+// Returns code which evaluates code and returns the result of then, which receives its value.
+func compileThen(code Code, then func(Object, *Environment) Object) Code {
+	return func(environment *Environment) Object {
+		return evalThen(code, environment, then)
+	}
+}
+
+// Compile an expression in tail position.
+// The code may return a tail call, which should be forced by caller.
+func compileTail(object Object, scope *Scope) Code {
+	switch object.(type) {
+	case *Application:
+		return compileApplication(object.(*Application), scope)
+	case *Variable:
+		return compileReference(object.(*Variable), scope)
+	case *Vector:
+		// identifiers renamed by a template are data in a vector literal
+		vector := unwrapAliases(object)
+		return func(environment *Environment) Object {
+			return vector
+		}
+	default:
+		return func(environment *Environment) Object {
+			return object
+		}
+	}
+}
+
+func compileAll(objects []Object, scope *Scope) []Code {
+	codes := []Code{}
+	for _, object := range objects {
+		codes = append(codes, compile(object, scope))
+	}
+	return codes
+}
+
+// Compile a body whose last expression is in tail position.
+// When 'objects' is empty, the code returns #<undef>.
+func compileBody(objects []Object, scope *Scope) Code {
+	if len(objects) == 0 {
+		return func(environment *Environment) Object {
+			return undef
+		}
+	}
+
+	scanDefinitions(objects, scope)
+	codes := compileAll(objects[:len(objects)-1], scope)
+	last := compileTail(objects[len(objects)-1], scope)
+	return func(environment *Environment) Object {
+		return evalSequence(codes, last, environment)
+	}
+}
+
+// Evaluate codes in order, and returns the result of last in tail position.
+func evalSequence(codes []Code, last Code, environment *Environment) Object {
+	for index, code := range codes {
+		index := index
+		evalPoint(code, environment, func() resumption {
+			return func(Object) Object {
+				return evalSequence(codes[index+1:], last, environment)
+			}
+		})
+	}
+	return last(environment)
+}
+
+// Allocate slots for definitions in body before compiling it,
+// so that a procedure defined in body can refer another one defined later.
+func scanDefinitions(objects []Object, scope *Scope) {
+	if scope.isGlobal() {
+		return
+	}
+
+	for _, object := range objects {
+		if !object.isApplication() || !object.(*Application).procedure.isVariable() {
+			continue
+		}
+		application := object.(*Application)
+
+		keyword := scope.staticValue(application.procedure)
+		switch {
+		case isSyntaxOf(keyword, "define"):
+			if !application.arguments.isPair() {
+				continue
+			}
+			variable := application.arguments.(*Pair).Car
+			if variable.isApplication() {
+				variable = variable.(*Application).procedure
+			}
+			if variable.isVariable() {
+				scope.allocate(variable.(*Variable).identifier)
+			}
+		case isSyntaxOf(keyword, "define-values"):
+			if application.arguments.isPair() {
+				for _, variable := range formalVariables(application.arguments.(*Pair).Car) {
+					scope.allocate(variable.(*Variable).identifier)
+				}
+			}
+		case isSyntaxOf(keyword, "define-record-type"):
+			for _, variable := range recordTypeVariables(application.arguments) {
+				scope.allocate(variable.(*Variable).identifier)
+			}
+		case isSyntaxOf(keyword, "begin"):
+			if application.arguments.isList() {
+				scanDefinitions(application.arguments.(*Pair).Elements(), scope)
+			}
+		}
+	}
+}
+
+// Returns variables in formals, which is a variable or a possibly dotted list.
+func formalVariables(formals Object) []Object {
+	if formals.isApplication() {
+		formals = formals.(*Application).toList()
+	}
+
+	variables := []Object{}
+	for ; formals.isPair(); formals = formals.(*Pair).Cdr {
+		if formals.(*Pair).Car.isVariable() {
+			variables = append(variables, formals.(*Pair).Car)
+		}
+	}
+	if formals.isVariable() {
+		variables = append(variables, formals)
+	}
+	return variables
+}
+
+// Returns variables defined by arguments of define-record-type, except field names.
+func recordTypeVariables(arguments Object) []Object {
+	if !arguments.isList() {
+		return nil
+	}
+
+	variables := []Object{}
+	for index, element := range arguments.(*Pair).Elements() {
+		switch {
+		case element.isVariable() && index < 3:
+			variables = append(variables, element)
+		case element.isApplication() && index == 1 && element.(*Application).procedure.isVariable():
+			variables = append(variables, element.(*Application).procedure)
+		case element.isApplication() && index > 2:
+			variables = append(variables, formalVariables(element.(*Application).arguments)...)
+		}
+	}
+	return variables
+}
+
+func compileApplication(application *Application, scope *Scope) Code {
+	location := application.Location()
+	defer func() {
+		// an error in compilation is located at the innermost form
+		if r := recover(); r != nil {
+			locate(r, location)
+			panic(r)
+		}
+	}()
+
+	if isQuotation(application) && !isSyntaxOf(scope.staticValue(application.procedure), "quote") {
+		application = analyzeQuotation(application)
+	}
+	if application.procedure.isVariable() {
+		switch object := scope.staticValue(application.procedure); object.(type) {
+		case *Syntax:
+			return object.(*Syntax).compile(application, scope)
+		case Expander:
+			return compileExpansion(object.(Expander), application, scope)
+		}
+	}
+
+	assertListMinimum(application.arguments, 0)
+	procedureCode := compile(application.procedure, scope)
+	argumentCodes := compileAll(application.arguments.(*Pair).Elements(), scope)
+
+	apply := func(procedure Object, environment *Environment) Object {
+		switch procedure.(type) {
+		case *Closure, *Subroutine, *Continuation:
+			arguments := evalCodes(argumentCodes, environment, func() resumption {
+				return func(values Object) Object {
+					arguments := valuesOf(values)
+					recordCall(environment, procedure, arguments, location)
+					return applyTail(procedure, arguments, environment)
+				}
+			})
+			recordCall(environment, procedure, arguments, location)
+			return applyTail(procedure, arguments, environment)
+		case *Actor:
+			return procedure.(*Actor).Invoke(application.arguments, argumentCodes, environment)
+		case *Syntax:
+			// syntax which is not known at compile time, e.g. a global variable defined later
+			return compileLater(func() Code { return procedure.(*Syntax).compile(application, scope) }, environment)(environment)
+		case Expander:
+			return compileLater(func() Code { return compileExpansion(procedure.(Expander), application, scope) }, environment)(environment)
+		default:
+			return raiseErrorIn(environment, errorKindInvalidApplication, "invalid application")
+		}
+	}
+	if !application.procedure.isApplication() {
+		// procedure is a variable or a literal, which does not capture continuations
+		return func(environment *Environment) Object {
+			return apply(procedureCode(environment), environment)
+		}
+	}
+	return compileThen(procedureCode, apply)
+}
+
+// Compile code at runtime, and count errors in compilation by the evaluation of environment.
+func compileLater(compile func() Code, environment *Environment) Code {
+	defer func() { pend(recover(), environment.evaluation) }()
+	return compile()
+}
+
+// Expand a macro use and compile its expansion in the scope of macro use.
+func compileExpansion(expander Expander, application *Application, scope *Scope) Code {
+	expansion := toExpression(expander.Expand(toDatumList(application.arguments), scope), application.Parent())
+	if expansion.isApplication() && expansion.Location() == nil {
+		// errors in expansion are located at the macro use
+		expansion.setLocation(application.Location())
+	}
+	return compileTail(expansion, scope)
+}
+
+func compileReference(variable *Variable, scope *Scope) Code {
+	reference := scope.resolve(variable)
+
+	if reference.local {
+		depth, index := reference.depth, reference.index
+		return func(environment *Environment) Object {
+			object := environment.frame(depth).get(index)
+			if object == nil {
+				raiseErrorIn(environment, errorKindUnboundVariable, "unbound variable: %s", variable)
+			}
+			return object
+		}
+	}
+
+	global := scope.global()
+	return func(environment *Environment) Object {
+		object := global.lookup(reference.identifiers...)
+		if object == nil {
+			raiseErrorIn(environment, errorKindUnboundVariable, "unbound variable: %s", variable)
+		}
+		return object
+	}
+}
+
+// Compile update of variable's value. A free alias updates the variable in macro's scope.
+func compileAssignment(variable *Variable, scope *Scope, valueCode Code) Code {
+	reference := scope.resolve(variable)
+
+	if reference.local {
+		depth, index := reference.depth, reference.index
+		assign := func(value Object, environment *Environment) Object {
+			environment.frame(depth).put(index, value)
+			return value
+		}
+		return compileThen(valueCode, assign)
+	}
+
+	global := scope.global()
+	assign := func(value Object, environment *Environment) Object {
+		if !global.set(reference.identifiers, value) {
+			raiseErrorIn(environment, errorKindRuntime, "symbol not defined")
+		}
+		return value
+	}
+	return compileThen(valueCode, assign)
+}
+
+// Compile definition of variable in the most inner scope.
+func compileDefinition(variable *Variable, scope *Scope, valueCode Code) Code {
+	if scope.isGlobal() {
+		global := scope.global()
+		define := func(value Object, environment *Environment) Object {
+			global.define(variable.identifier, nameObject(value, variable))
+			return NewSymbol(variable.identifier)
+		}
+		return compileThen(valueCode, define)
+	}
+
+	index := scope.allocate(variable.identifier)
+	delete(scope.macros, index)
+	define := func(value Object, environment *Environment) Object {
+		environment.put(index, nameObject(value, variable))
+		return NewSymbol(variable.identifier)
+	}
+	return compileThen(valueCode, define)
+}
+
+// Compile lambda whose parameters are variables and rest.
+// When rest is not nil, it is bound to a list of arguments following variables.
+func compileLambda(variables []Object, rest Object, body []Object, scope *Scope) Code {
+	bodyScope := NewScope(scope, variables...)
+	if rest != nil {
+		bodyScope.allocate(variableName(rest))
+	}
+	bodyCode := compileBody(body, bodyScope)
+
+	return func(environment *Environment) Object {
+		closure := NewClosure(environment)
+		closure.DefineFunction(len(variables), rest != nil, bodyScope, bodyCode)
+		return closure
+	}
+}
+
+// Call procedure with evaluated arguments from Go code,
+// e.g. a subroutine which receives procedures as its arguments.
+func applyProcedure(procedure Object, arguments []Object, environment *Environment) Object {
+	enterEval(environment)
+	frame := pushFrame(environment, nil)
+	frame.Procedure, frame.Arguments = procedure, arguments
+	defer func() {
+		leaveEval(environment)
+		popFrame(environment, frame)
+		if isCapturing(environment) {
+			suspend(recover(), forceFrame(environment))
+		}
+	}()
+	defer func() {
+		if isRaising(environment) {
+			handle(recover(), environment)
+		}
+	}()
+	return force(applyTail(procedure, arguments, environment))
+}
+
+// Apply procedure to evaluated arguments in tail position.
+// A closure is not called but returned as a tail call.
+func applyTail(procedure Object, arguments []Object, environment *Environment) Object {
+	switch procedure.(type) {
+	case *Closure:
+		return NewTailCall(procedure.(*Closure), arguments, environment.evaluation)
+	case *Subroutine:
+		return procedure.(*Subroutine).Call(NewList(nil, arguments...), environment)
+	case *Continuation:
+		return procedure.(*Continuation).throw(arguments)
+	default:
+		return raiseErrorIn(environment, errorKindInvalidApplication, "invalid application")
+	}
+}
+
+// Evaluate codes in order, and returns their values.
+// If a continuation is captured in codes, rest makes a frame which receives the values as Values.
+func evalCodes(codes []Code, environment *Environment, rest func() resumption) []Object {
+	return evalCodesAfter(nil, codes, environment, rest)
+}
+
+// Evaluate codes following objects which are already evaluated, and returns all of them.
+func evalCodesAfter(evaluated []Object, codes []Code, environment *Environment, rest func() resumption) []Object {
+	objects := make([]Object, len(evaluated)+len(codes))
+	copy(objects, evaluated)
+	for index, code := range codes {
+		index := index
+		objects[len(evaluated)+index] = evalPoint(code, environment, func() resumption {
+			evaluated, resume := objects[:len(evaluated)+index], rest()
+			return func(object Object) Object {
+				evaluated := append(evaluated[:len(evaluated):len(evaluated)], object)
+				objects := evalCodesAfter(evaluated, codes[index+1:], environment, func() resumption {
+					return resume
+				})
+				return resume(&Values{objects: objects})
+			}
+		})
+	}
+	return objects
+}
+
+// Evaluate codes and returns the result of then, which receives their values.
+// Then is also a frame of the evaluation points, so that it should be
+// created at compile time to avoid allocation at runtime.
+func evalCodesThen(codes []Code, environment *Environment, then func([]Object, *Environment) Object) Object {
+	return then(evalCodes(codes, environment, func() resumption {
+		return func(values Object) Object {
+			return then(valuesOf(values), environment)
+		}
+	}), environment)
+}
+
+// Name an object by variable which is bound to it at first.
+func nameObject(object Object, variable *Variable) Object {
+	if object.Bounder() == nil {
+		object.setBounder(variable)
+	}
+	return object
+}
@@ -0,0 +1,243 @@
+// Continuation is a first-class continuation captured by call/cc.
+//
+// Compiled code is evaluated on Go's stack, which cannot be copied.
+// While call/cc is on the stack, its continuation is an escape point and
+// invoking it unwinds the stack to call/cc by panic. This is the fast path
+// for escape-only use, such as early exits from loops.
+//
+// When call/cc leaves the stack, its continuation is captured: the stack
+// is unwound by panic instead of returning, and each evaluation point which
+// has rest of computation records a frame to resume it. The recorded frames
+// are resumed by the root of evaluation, and they can be resumed again when
+// the continuation is re-entered.
+
+package scheme
+
+const (
+	continuationLive     = iota // call/cc is on Go's stack
+	continuationCaptured        // frames are captured
+	continuationLost            // call/cc left the stack by error
+)
+
+type Continuation struct {
+	ObjectBase
+	state      int
+	escapeOnly bool          // never captured, created by call/ec
+	frames     []resumption  // rest of computation, innermost first
+	root       *root         // root which resumes frames
+	handlers   *handlerStack // exception handlers installed at call/cc
+	ports      ports         // current ports at call/cc
+	evaluation *evaluation   // evaluation of call/cc, which counts signals capturing its frames
+}
+
+// Resumption is a frame which resumes rest of computation suspended at an
+// evaluation point with the value of its subexpression.
+type resumption func(Object) Object
+
+// Root is a point where Go's stack is not unwound by continuations,
+// such as the top level of a program or a procedure called from Go code.
+type root struct {
+	nested bool
+}
+
+// Panic value to unwind Go's stack for continuations.
+type continuationSignal struct {
+	target   *Continuation // invoked continuation, or nil to resume frames at root
+	value    Object
+	frames   []resumption  // frames recorded while unwinding, innermost first
+	captures []capture     // continuations waiting for frames
+	resume   int           // index of frames which receive value
+	handlers *handlerStack // exception handlers restored to resume frames
+	ports    ports         // current ports restored to resume frames
+}
+
+// Continuation captured by a signal, whose frames start from index.
+type capture struct {
+	continuation *Continuation
+	index        int
+}
+
+func NewContinuation(escapeOnly bool) *Continuation {
+	return &Continuation{state: continuationLive, escapeOnly: escapeOnly}
+}
+
+func (c *Continuation) String() string {
+	return "#<continuation>"
+}
+
+func (c *Continuation) isProcedure() bool {
+	return true
+}
+
+// Call receiver with this continuation on Go's stack, and returns the value
+// passed to the continuation or returned by receiver.
+func (c *Continuation) call(receiver Object, environment *Environment) (result Object) {
+	c.evaluation = environment.evaluation
+	c.handlers = c.evaluation.handlers
+	c.ports = c.evaluation.ports
+	defer func() {
+		if r := recover(); r != nil {
+			result = c.unwind(r)
+		}
+	}()
+
+	value := applyProcedure(receiver, []Object{c}, environment)
+	if c.escapeOnly {
+		c.state = continuationLost
+		return value
+	}
+
+	// call/cc is leaving Go's stack, so its continuation is captured by unwinding
+	panic(&continuationSignal{value: value, handlers: c.handlers, ports: c.ports})
+}
+
+// Handle a panic which unwinds the stack of call/cc.
+// Returns a value if call/cc returns it, otherwise continues unwinding.
+func (c *Continuation) unwind(r interface{}) Object {
+	signal, ok := r.(*continuationSignal)
+	if !ok || (c.escapeOnly && signal.target != c) {
+		// error or other continuation is unwinding the stack
+		c.state = continuationLost
+		panic(r)
+	}
+
+	if signal.target == c {
+		if c.escapeOnly && len(signal.captures) == 0 {
+			c.state = continuationLost
+			return signal.value
+		}
+		// the value is passed to frames outside of call/cc after they are captured
+		signal.target = nil
+		signal.resume = len(signal.frames)
+	}
+
+	if c.escapeOnly {
+		c.state = continuationLost
+	} else {
+		if len(signal.captures) == 0 {
+			c.evaluation.capturing++
+		}
+		signal.captures = append(signal.captures, capture{continuation: c, index: len(signal.frames)})
+	}
+	panic(signal)
+}
+
+// Invoke continuation with values.
+func (c *Continuation) throw(objects []Object) Object {
+	panic(&continuationSignal{target: c, value: NewValues(objects), handlers: c.handlers, ports: c.ports})
+}
+
+// Returns true if a signal is capturing frames in the evaluation of environment.
+// While this is false, evaluation points do not need to recover panics.
+func isCapturing(environment *Environment) bool {
+	return environment.evaluation.capturing > 0
+}
+
+// Record a frame made by rest to a capturing signal and continue unwinding.
+// This must be called by a deferred function with the value of recover().
+func suspend(r interface{}, rest func() resumption) {
+	if r == nil {
+		return
+	}
+	if signal, ok := r.(*continuationSignal); ok && len(signal.captures) > 0 {
+		signal.frames = append(signal.frames, rest())
+	}
+	panic(r)
+}
+
+// Evaluate code at an evaluation point, which has rest of computation on Go's stack.
+// If a continuation is captured in code, rest makes a frame to resume the computation.
+func evalPoint(code Code, environment *Environment, rest func() resumption) Object {
+	defer func() {
+		if isCapturing(environment) {
+			suspend(recover(), rest)
+		}
+	}()
+	return code(environment)
+}
+
+// Evaluate code and returns the result of then, which receives its value.
+// Then is also a frame of the evaluation point, so that it should be
+// created at compile time to avoid allocation at runtime.
+func evalThen(code Code, environment *Environment, then func(Object, *Environment) Object) Object {
+	return then(evalPoint(code, environment, func() resumption {
+		return func(object Object) Object {
+			return then(object, environment)
+		}
+	}), environment)
+}
+
+// Evaluate thunk as a root of continuations in evaluation.
+// Frames captured in thunk are resumed here after Go's stack is unwound.
+// If nested is true, a signal for a continuation of outer roots is thrown to outer stack.
+func evalRoot(thunk func() Object, evaluation *evaluation, nested bool) Object {
+	r := &root{nested: nested}
+	pending := []resumption{}
+	for {
+		value, signal := evalSignal(thunk)
+		if signal != nil {
+			if nested && signal.target != nil && signal.target.root != r {
+				if len(signal.captures) > 0 && signal.target.evaluation != evaluation {
+					// frames of other evaluation are not recorded for the captured continuations
+					for _, capture := range signal.captures {
+						capture.continuation.state = continuationLost
+					}
+					signal.captures = nil
+					evaluation.capturing--
+				}
+				// rest of computation in this root is resumed by outer root
+				signal.frames = append(signal.frames, pending...)
+				panic(signal)
+			}
+			if signal.target != nil && signal.target.state == continuationLive {
+				signal.value = nil
+			}
+
+			frames := append(signal.frames, pending...)
+			if len(signal.captures) > 0 {
+				for _, capture := range signal.captures {
+					capture.continuation.frames = frames[capture.index:]
+					capture.continuation.root = r
+					capture.continuation.state = continuationCaptured
+				}
+				evaluation.capturing--
+			}
+
+			pending = frames[signal.resume:]
+			if signal.target != nil {
+				if signal.value == nil {
+					raiseError(errorKindContinuation, "continuation is invoked out of its thread")
+				} else if signal.target.state != continuationCaptured {
+					raiseError(errorKindContinuation, "continuation is no longer available")
+				}
+				pending = signal.target.frames
+			}
+			value = signal.value
+			evaluation.handlers = signal.handlers
+			evaluation.ports = signal.ports
+		}
+
+		if len(pending) == 0 {
+			return value
+		}
+		resume := pending[0]
+		pending = pending[1:]
+		thunk = func() Object {
+			return resume(value)
+		}
+	}
+}
+
+// Evaluate thunk, and returns a signal if it unwinds the stack.
+func evalSignal(thunk func() Object) (value Object, signal *continuationSignal) {
+	defer func() {
+		if r := recover(); r != nil {
+			s, ok := r.(*continuationSignal)
+			if !ok {
+				panic(r)
+			}
+			signal = s
+		}
+	}()
+	return thunk(), nil
+}
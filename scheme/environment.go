@@ -0,0 +1,109 @@
+// Environment is a frame of variable bindings which is created at runtime.
+// Each closure call creates a fresh frame chained to the environment
+// where the closure is defined, so that recursive or concurrent calls of
+// the same closure do not share their arguments.
+//
+// A local frame stores values in slots whose indices are resolved by Scope
+// at compile time. Only the global environment binds variables by name.
+
+package scheme
+
+import (
+	"sync"
+)
+
+type Environment struct {
+	parent     *Environment
+	values     []Object
+	binding    Binding     // only for global environment
+	evaluation *evaluation // nil for global environment
+	mutex      sync.RWMutex
+}
+
+// A frame belongs to the evaluation of its parent.
+func NewEnvironment(parent *Environment, size int) *Environment {
+	environment := &Environment{parent: parent, values: make([]Object, size)}
+	if parent != nil {
+		environment.evaluation = parent.evaluation
+	}
+	return environment
+}
+
+func NewGlobalEnvironment(binding Binding) *Environment {
+	return &Environment{binding: binding}
+}
+
+// Returns the frame which is depth frames outer than this frame.
+func (e *Environment) frame(depth int) *Environment {
+	environment := e
+	for ; depth > 0; depth-- {
+		environment = environment.parent
+	}
+	return environment
+}
+
+// Returns the global environment which this frame is chained to.
+func (e *Environment) global() *Environment {
+	environment := e
+	for environment.parent != nil {
+		environment = environment.parent
+	}
+	return environment
+}
+
+// Returns the value in slot, or nil if it is not initialized yet.
+func (e *Environment) get(index int) Object {
+	if index < len(e.values) {
+		return e.values[index]
+	}
+	return nil
+}
+
+// Store value into slot. A slot added to scope after this frame is created
+// is allocated here.
+func (e *Environment) put(index int, object Object) {
+	for index >= len(e.values) {
+		e.values = append(e.values, nil)
+	}
+	e.values[index] = object
+}
+
+// Returns the object bound to the first bound identifier in global environment,
+// or nil if none of identifiers are bound.
+func (e *Environment) lookup(identifiers ...string) Object {
+	global := e.global()
+	global.mutex.RLock()
+	defer global.mutex.RUnlock()
+
+	for _, identifier := range identifiers {
+		if object := global.binding[identifier]; object != nil {
+			return object
+		}
+	}
+	return nil
+}
+
+// This method is for define syntax form.
+// Define a variable in global environment.
+func (e *Environment) define(identifier string, object Object) {
+	global := e.global()
+	global.mutex.Lock()
+	global.binding[identifier] = object
+	global.mutex.Unlock()
+}
+
+// This method is for set! syntax form.
+// Update the first bound identifier in global environment, and returns false if none is bound.
+func (e *Environment) set(identifiers []string, object Object) bool {
+	global := e.global()
+	global.mutex.Lock()
+	defer global.mutex.Unlock()
+
+	for _, identifier := range identifiers {
+		if _, ok := global.binding[identifier]; ok {
+			global.binding[identifier] = object
+			return true
+		}
+	}
+	return false
+}
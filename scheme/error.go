@@ -0,0 +1,101 @@
+// SchemeError is an error returned by Interpreter.Eval.
+// It is made from an object which is raised without handlers,
+// so that Go code can know what went wrong without parsing messages.
+
+package scheme
+
+import (
+	"fmt"
+)
+
+type ErrorCategory int
+
+const (
+	SyntaxError  ErrorCategory = iota // source code or syntax form is malformed
+	CompileError                      // expression cannot be compiled
+	RuntimeError                      // built-in error in evaluation
+	UserError                         // raised by raise or error procedure
+)
+
+type SchemeError struct {
+	Category  ErrorCategory
+	Message   string
+	Irritants []Object
+	Location  *Location // nil if unknown
+	Object    Object    // raised object
+	Frames    []Frame   // backtrace from the innermost frame
+}
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case SyntaxError:
+		return "syntax error"
+	case CompileError:
+		return "compile error"
+	case RuntimeError:
+		return "runtime error"
+	case UserError:
+		return "user error"
+	default:
+		return fmt.Sprintf("ErrorCategory(%d)", int(c))
+	}
+}
+
+// Make SchemeError from the value of recover(), which aborted evaluation.
+func NewSchemeError(r interface{}) *SchemeError {
+	if p, ok := r.(*pendingError); ok {
+		// a built-in error raised out of applications, which has no handler
+		p.count(nil)
+		r = newUncaughtException(p.errorObject, nil)
+	}
+	u, ok := r.(*uncaughtException)
+	if !ok {
+		// Go's runtime error or an unexpected panic
+		return &SchemeError{Category: RuntimeError, Message: fmt.Sprint(r)}
+	}
+
+	e := &SchemeError{Message: u.String(), Location: u.where(), Object: u.object, Frames: u.frames}
+	errorObject, ok := u.object.(*ErrorObject)
+	if !ok {
+		e.Category = UserError
+		e.Irritants = []Object{u.object}
+		return e
+	}
+
+	e.Irritants = errorObject.irritants
+	switch errorObject.kind {
+	case errorKindUser:
+		e.Category = UserError
+	case errorKindRead, errorKindSyntax:
+		e.Category = SyntaxError
+	case errorKindCompile:
+		e.Category = CompileError
+	default:
+		e.Category = RuntimeError
+	}
+	return e
+}
+
+// Returns a backtrace to be printed after error message, or empty string if it has no frame.
+func (e *SchemeError) Backtrace() string {
+	if len(e.Frames) == 0 {
+		return ""
+	}
+	return "Stack Trace:\n" + formatBacktrace(e.Frames)
+}
+
+// Location is shown only for source code loaded from file, whose line is meaningful.
+func (e *SchemeError) Error() string {
+	if e.Location == nil || e.Location.File == "" {
+		return e.report()
+	}
+	return fmt.Sprintf("%s: %s", e.Location, e.report())
+}
+
+// Returns message for error report, which follows the heading of a built-in error.
+func (e *SchemeError) report() string {
+	if errorObject, ok := e.Object.(*ErrorObject); ok {
+		return errorObject.heading + e.Message
+	}
+	return e.Message
+}
@@ -0,0 +1,31 @@
+// Evaluation is the dynamic state of a thread of evaluation, such as the
+// nesting level of applications, frames of backtrace, exception handlers and
+// current ports. Each entry point from Go code, e.g. an evaluation by
+// Interpreter, a call of closure from Go or a message to an actor, starts an
+// evaluation. Environment frames created in the evaluation refer to it, and a
+// closure call passes the evaluation of its caller to the frame of the call.
+// An evaluation is used by one goroutine at a time, so that concurrent
+// evaluations do not share their state.
+
+package scheme
+
+type evaluation struct {
+	depth     int64         // nesting level of non-tail applications
+	frame     *callFrame    // innermost frame of backtrace
+	handlers  *handlerStack // installed exception handlers
+	ports     ports         // current input, output and error ports
+	capturing int           // number of unwinding signals which are capturing frames
+	raising   int           // number of built-in errors which are unwinding the stack
+}
+
+// Returns a frame which evaluates code in environment with evaluation.
+func withEvaluation(environment *Environment, evaluation *evaluation) *Environment {
+	frame := NewEnvironment(environment, 0)
+	frame.evaluation = evaluation
+	return frame
+}
+
+// Returns a frame which starts a new evaluation in environment.
+func newEvaluation(environment *Environment) *Environment {
+	return withEvaluation(environment, &evaluation{ports: standardPorts})
+}
@@ -0,0 +1,356 @@
+// Exception handlers are installed by with-exception-handler and guard to
+// the evaluation, and an object raised by raise or a built-in error is passed
+// to the most recently installed handler. The handler is called on Go's stack
+// of raise, so that raise-continuable can return the value of handler. A guard
+// tests its clauses in the handler and escapes from it by panic, and an object
+// raised without handlers aborts the evaluation.
+//
+// A built-in error is raised where its evaluation is unknown, e.g. in a type
+// assertion of subroutine. It is counted by the evaluation where the Go code
+// is called, and unwinds Go's stack to the innermost evaluation point of the
+// evaluation, where it is passed to handlers.
+//
+// ErrorObject is a condition object raised by error procedure and built-in
+// errors, such as "unbound variable". It has a kind to classify errors.
+
+package scheme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of error objects
+const (
+	errorKindUser               = "error" // raised by error procedure
+	errorKindRuntime            = "runtime-error"
+	errorKindCompile            = "compile-error"
+	errorKindRead               = "read-error"
+	errorKindSyntax             = "syntax-error"
+	errorKindUnboundVariable    = "unbound-variable"
+	errorKindWrongType          = "wrong-type-argument"
+	errorKindArity              = "wrong-number-of-arguments"
+	errorKindInvalidApplication = "invalid-application"
+	errorKindStackOverflow      = "stack-overflow"
+	errorKindHandlerReturned    = "handler-returned"
+	errorKindContinuation       = "continuation-error"
+	errorKindDivisionByZero     = "division-by-zero"
+	errorKindOutOfRange         = "out-of-range"
+	errorKindFile               = "file-error"
+)
+
+type handlerStack struct {
+	handler Object
+	next    *handlerStack // handlers installed outside of handler
+}
+
+type ErrorObject struct {
+	ObjectBase
+	kind      string
+	message   string
+	irritants []Object
+	location  *Location // where the error occurred in source code, if known
+	heading   string    // prepended to message in error report, e.g. "Compile Error: "
+}
+
+// Panic value to abort evaluation by an object which is raised without handlers.
+type uncaughtException struct {
+	object   Object
+	location *Location // where the object is raised, if known
+	frames   []Frame   // backtrace where the object is raised
+}
+
+// Panic value to raise a built-in error at an evaluation point.
+type pendingError struct {
+	errorObject *ErrorObject
+	evaluation  *evaluation // evaluation which counts the error, or nil if it is not counted
+}
+
+// Panic value for guard to escape from its handler with a clause satisfied by a raised object.
+type guardSignal struct {
+	ObjectBase
+	guard       *guard
+	clause      *clause
+	value       Object       // value of the test of clause
+	environment *Environment // frame of clauses, which binds the raised object
+}
+
+type guard struct {
+	live        bool // guard is on Go's stack
+	handler     *Subroutine
+	exit        *Continuation // captured when guard leaves the stack by capturing signal
+	clauses     Code          // returns a guardSignal of satisfied clause, or #<undef>
+	size        int           // size of frame of clauses
+	environment *Environment  // where guard is evaluated
+	handlers    *handlerStack // handlers installed outside of guard
+	ports       ports         // current ports of guard
+}
+
+func NewErrorObject(kind string, message string, irritants []Object) *ErrorObject {
+	return &ErrorObject{kind: kind, message: message, irritants: irritants}
+}
+
+func (e *ErrorObject) String() string {
+	return fmt.Sprintf("#<error %q>", e.message)
+}
+
+// Returns message for error report. An error raised by error procedure
+// is followed by its irritants, which are included in message of built-in errors.
+func (e *ErrorObject) report() string {
+	if e.kind != errorKindUser {
+		return e.message
+	}
+
+	tokens := []string{e.message}
+	for _, irritant := range e.irritants {
+		tokens = append(tokens, irritant.String())
+	}
+	return strings.Join(tokens, " ")
+}
+
+func (u *uncaughtException) String() string {
+	if errorObject, ok := u.object.(*ErrorObject); ok {
+		return errorObject.report()
+	}
+	return fmt.Sprintf("unhandled exception: %s", u.object)
+}
+
+// Record location to an uncaught exception r recovered by a deferred function if it is not known,
+// e.g. an error raised in compilation of the form at location.
+func locate(r interface{}, location *Location) {
+	if u, ok := r.(*uncaughtException); ok && u.location == nil {
+		u.location = location
+	} else if p, ok := r.(*pendingError); ok && p.errorObject.location == nil {
+		p.errorObject.location = location
+	}
+}
+
+// Returns where the object is raised, or the call-site of the innermost frame.
+func (u *uncaughtException) where() *Location {
+	if u.location == nil && len(u.frames) > 0 {
+		return u.frames[0].Location
+	}
+	return u.location
+}
+
+// Returns true if a built-in error is unwinding the stack of the evaluation of environment, like isCapturing.
+func isRaising(environment *Environment) bool {
+	return environment.evaluation.raising > 0
+}
+
+// Raise an error object of kind, whose message is formatted by format and a.
+// Scheme objects in a are irritants of the error object, and expressions of AST
+// are converted into data.
+func raiseError(kind string, format string, a ...interface{}) Object {
+	return raiseErrorWithHeading("", kind, format, a...)
+}
+
+// Raise an error object like raiseError, whose message is reported after heading.
+func raiseErrorWithHeading(heading string, kind string, format string, a ...interface{}) Object {
+	var location *Location
+	irritants := []Object{}
+	for _, argument := range a {
+		switch argument.(type) {
+		case *Application, *Variable:
+			// a form is more informative than a keyword in it
+			if location == nil || argument.(Object).isApplication() {
+				location = argument.(Object).Location()
+			}
+			irritants = append(irritants, toDatum(argument.(Object)))
+		case Object:
+			irritants = append(irritants, argument.(Object))
+		}
+	}
+
+	errorObject := NewErrorObject(kind, fmt.Sprintf(format, a...), irritants)
+	errorObject.location = location
+	errorObject.heading = heading
+	return raiseBuiltin(errorObject)
+}
+
+// Raise a built-in error, which is passed to handlers at an evaluation point
+// after it is counted by the evaluation of caller.
+func raiseBuiltin(errorObject *ErrorObject) Object {
+	panic(&pendingError{errorObject: errorObject})
+}
+
+// Raise a built-in error like raiseError, which is counted by the evaluation of environment.
+func raiseErrorIn(environment *Environment, kind string, format string, a ...interface{}) Object {
+	defer func() { pend(recover(), environment.evaluation) }()
+	return raiseError(kind, format, a...)
+}
+
+// Count a built-in error by evaluation, which called Go code raising it, and continue unwinding.
+// This must be called by a deferred function with the value of recover().
+func pend(r interface{}, evaluation *evaluation) {
+	if r == nil {
+		return
+	}
+	if p, ok := r.(*pendingError); ok && p.evaluation != evaluation {
+		p.count(evaluation)
+	}
+	panic(r)
+}
+
+// Move the count of error to evaluation, or discard it if evaluation is nil.
+func (p *pendingError) count(evaluation *evaluation) {
+	if p.evaluation != nil {
+		p.evaluation.raising--
+	}
+	if evaluation != nil {
+		evaluation.raising++
+	}
+	p.evaluation = evaluation
+}
+
+// Raise a built-in error recovered by a deferred function to the handlers of environment.
+// Other panics continue unwinding.
+func handle(r interface{}, environment *Environment) {
+	if r == nil {
+		return
+	}
+	p, ok := r.(*pendingError)
+	if !ok {
+		panic(r)
+	}
+	if p.errorObject.kind == errorKindStackOverflow && !hasHeadroom(environment) {
+		// handlers are called after the stack is unwound to have room for them
+		panic(r)
+	}
+	p.count(nil)
+	raise(p.errorObject, false, environment)
+}
+
+// Evaluate code, and pass a built-in error raised in code to the handlers of environment.
+func evalHandled(code Code, environment *Environment) Object {
+	defer func() {
+		if isRaising(environment) {
+			handle(recover(), environment)
+		}
+	}()
+	return code(environment)
+}
+
+// Raise object to the current handler, which is called with outer handlers.
+// If continuable is true, returns the value of the handler.
+// Otherwise the handler must not return, and a secondary error is raised if it returns.
+func raise(object Object, continuable bool, environment *Environment) Object {
+	evaluation := environment.evaluation
+	stack := evaluation.handlers
+	if stack == nil {
+		panic(newUncaughtException(object, currentBacktrace(environment)))
+	}
+
+	evaluation.handlers = stack.next
+	defer func() { evaluation.handlers = stack }()
+	return evalThen(func(environment *Environment) Object {
+		return applyProcedure(stack.handler, []Object{object}, environment)
+	}, environment, func(result Object, environment *Environment) Object {
+		if !continuable {
+			// raised in the dynamic environment of the handler
+			message := fmt.Sprintf("exception handler returned from non-continuable raise: %s", object)
+			raise(NewErrorObject(errorKindHandlerReturned, message, []Object{object}), false, environment)
+		}
+		evaluation.handlers = stack
+		return result
+	})
+}
+
+func newUncaughtException(object Object, frames []Frame) *uncaughtException {
+	u := &uncaughtException{object: object, frames: frames}
+	if errorObject, ok := object.(*ErrorObject); ok {
+		u.location = errorObject.location
+	}
+	return u
+}
+
+// Call thunk with handler, which is installed while thunk is called.
+func withExceptionHandler(handler Object, thunk Object, environment *Environment) Object {
+	evaluation := environment.evaluation
+	stack := evaluation.handlers
+	evaluation.handlers = &handlerStack{handler: handler, next: stack}
+	defer func() { evaluation.handlers = stack }()
+	return evalThen(func(environment *Environment) Object {
+		return applyProcedure(thunk, []Object{}, environment)
+	}, environment, func(result Object, environment *Environment) Object {
+		evaluation.handlers = stack
+		return result
+	})
+}
+
+func newGuard(clauses Code, size int) *guard {
+	g := &guard{live: true, clauses: clauses, size: size}
+	g.handler = NewSubroutine(func(s *Subroutine, arguments Object, environment *Environment) Object {
+		condition := arguments.(*Pair).ElementAt(0)
+		if !g.live && g.exit == nil {
+			return raise(condition, true, environment)
+		}
+		return g.test(condition, environment)
+	})
+	g.handler.setBounder(NewVariable("guard", nil))
+	return g
+}
+
+// Test clauses with a raised object in the dynamic environment of guard, and escape to guard
+// with the satisfied clause. If no clause is satisfied, the object is raised again by
+// raise-continuable in the dynamic environment of raise, which is where the handler is called.
+func (g *guard) test(condition Object, environment *Environment) Object {
+	evaluation := environment.evaluation
+	handlers, ports := evaluation.handlers, evaluation.ports
+	evaluation.handlers, evaluation.ports = g.handlers, g.ports
+
+	frame := NewEnvironment(g.environment, g.size)
+	frame.put(0, condition)
+	return evalThen(g.clauses, frame, func(selected Object, _ *Environment) Object {
+		evaluation.handlers, evaluation.ports = handlers, ports
+		signal, ok := selected.(*guardSignal)
+		if !ok {
+			return raise(condition, true, environment)
+		}
+
+		signal.guard = g
+		if g.live {
+			panic(signal)
+		}
+		// guard body is re-entered by a continuation after guard left the stack
+		panic(&continuationSignal{target: g.exit, value: signal, handlers: g.exit.handlers, ports: g.exit.ports})
+	})
+}
+
+// Evaluate body with the handler of guard, and returns its value.
+// If an object is raised in body, returns the value of the clause satisfied by the object.
+func (g *guard) eval(body Code, environment *Environment) (result Object) {
+	evaluation := environment.evaluation
+	stack := evaluation.handlers
+	ports := evaluation.ports
+	g.environment, g.handlers, g.ports = environment, stack, ports
+	evaluation.handlers = &handlerStack{handler: g.handler, next: stack}
+	exit := func(value Object) Object {
+		evaluation.handlers = stack
+		if signal, ok := value.(*guardSignal); ok && signal.guard == g {
+			return signal.clause.eval(signal.value, signal.environment)
+		}
+		return value
+	}
+
+	defer func() {
+		g.live = false
+		if r := recover(); r != nil {
+			if signal, ok := r.(*guardSignal); ok && signal.guard == g {
+				result = exit(signal)
+				return
+			}
+
+			evaluation.handlers = stack
+			if signal, ok := r.(*continuationSignal); ok && len(signal.captures) > 0 {
+				// continuation to escape from body after it is re-entered
+				g.exit = NewContinuation(false)
+				g.exit.handlers = stack
+				g.exit.ports = ports
+				signal.captures = append(signal.captures, capture{continuation: g.exit, index: len(signal.frames)})
+			}
+			suspend(r, func() resumption { return exit })
+		}
+	}()
+	return exit(evalHandled(body, environment))
+}
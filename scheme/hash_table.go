@@ -0,0 +1,165 @@
+// HashTable is a type for scheme hash table object, which is created by
+// make-hash-table with an equality such as eq?, eqv?, equal? or string=?.
+// Keys are grouped into buckets of a map by their hash, and compared by the
+// equality in each bucket.
+
+package scheme
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+)
+
+type HashTable struct {
+	ObjectBase
+	equality *hashEquality
+	buckets  map[uint64][]*hashEntry
+	size     int
+}
+
+type hashEntry struct {
+	key   Object
+	value Object
+}
+
+// The hash of objects which are equal by equal must be the same.
+type hashEquality struct {
+	name  string
+	equal func(Object, Object) bool
+	hash  func(Object) uint64
+}
+
+var hashEqualities = map[string]*hashEquality{
+	"eq?":      &hashEquality{name: "eq?", equal: areIdentical, hash: identityHash},
+	"eqv?":     &hashEquality{name: "eqv?", equal: areIdentical, hash: identityHash},
+	"equal?":   &hashEquality{name: "equal?", equal: areEqual, hash: structuralHash},
+	"string=?": &hashEquality{name: "string=?", equal: areSameStrings, hash: stringHash},
+}
+
+func NewHashTable(equality *hashEquality, options ...Object) *HashTable {
+	table := &HashTable{equality: equality, buckets: map[uint64][]*hashEntry{}}
+	if len(options) > 0 {
+		table.parent = options[0]
+	}
+	return table
+}
+
+func (h *HashTable) String() string {
+	return fmt.Sprintf("#<hash-table %s>", h.equality.name)
+}
+
+func (h *HashTable) isHashTable() bool {
+	return true
+}
+
+// Returns the entry for key, or nil if it is not found.
+func (h *HashTable) lookup(key Object) *hashEntry {
+	for _, entry := range h.buckets[h.equality.hash(key)] {
+		if h.equality.equal(entry.key, key) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (h *HashTable) set(key Object, value Object) {
+	if entry := h.lookup(key); entry != nil {
+		entry.value = value
+		return
+	}
+
+	hash := h.equality.hash(key)
+	h.buckets[hash] = append(h.buckets[hash], &hashEntry{key: key, value: value})
+	h.size++
+}
+
+func (h *HashTable) delete(key Object) {
+	hash := h.equality.hash(key)
+	for index, entry := range h.buckets[hash] {
+		if h.equality.equal(entry.key, key) {
+			h.buckets[hash] = append(h.buckets[hash][:index:index], h.buckets[hash][index+1:]...)
+			if len(h.buckets[hash]) == 0 {
+				delete(h.buckets, hash)
+			}
+			h.size--
+			return
+		}
+	}
+}
+
+// Returns a snapshot of entries, so that the table can be modified while they are walked.
+// Their order is unspecified.
+func (h *HashTable) entries() []*hashEntry {
+	entries := make([]*hashEntry, 0, h.size)
+	for _, bucket := range h.buckets {
+		entries = append(entries, bucket...)
+	}
+	return entries
+}
+
+func areSameStrings(a Object, b Object) bool {
+	return a.(*String).text() == b.(*String).text()
+}
+
+// Hash consistent with areIdentical.
+func identityHash(object Object) uint64 {
+	switch object.(type) {
+	case *Number:
+		return textHash("number", object.String())
+	case *Boolean:
+		return textHash("boolean", object.String())
+	case *Char:
+		return textHash("char", object.String())
+	default:
+		return uint64(reflect.ValueOf(object).Pointer())
+	}
+}
+
+// Hash consistent with areEqual, which is computed from texts of strings and elements of pairs, vectors and records.
+func structuralHash(object Object) uint64 {
+	switch object.(type) {
+	case *Pair:
+		if object.isNull() {
+			return textHash("null", "")
+		}
+		return combineHash(textHash("pair", ""), structuralHash(object.(*Pair).Car), structuralHash(object.(*Pair).Cdr))
+	case *Vector:
+		hashes := []uint64{textHash("vector", "")}
+		for _, element := range object.(*Vector).elements {
+			hashes = append(hashes, structuralHash(element))
+		}
+		return combineHash(hashes...)
+	case *String:
+		return stringHash(object)
+	case *Record:
+		hashes := []uint64{identityHash(object.(*Record).recordType)}
+		for _, field := range object.(*Record).fields {
+			hashes = append(hashes, structuralHash(field))
+		}
+		return combineHash(hashes...)
+	default:
+		return identityHash(object)
+	}
+}
+
+func stringHash(object Object) uint64 {
+	assertObjectType(object, "string")
+	return textHash("string", object.(*String).text())
+}
+
+func textHash(typeName string, text string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(typeName))
+	hash.Write([]byte{0})
+	hash.Write([]byte(text))
+	return hash.Sum64()
+}
+
+func combineHash(hashes ...uint64) uint64 {
+	result := uint64(14695981039346656037)
+	for _, hash := range hashes {
+		result = (result ^ hash) * 1099511628211
+	}
+	return result
+}
@@ -10,22 +10,18 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 )
 
 type Interpreter struct {
 	*Parser
-	closure *Closure
+	environment *Environment
 }
 
 func NewInterpreter(source string) *Interpreter {
 	i := &Interpreter{
-		Parser: NewParser(source),
-		closure: &Closure{
-			ObjectBase:   ObjectBase{parent: nil},
-			localBinding: defaultBinding(),
-		},
+		Parser:      NewParser(source),
+		environment: NewGlobalEnvironment(defaultBinding()),
 	}
 	i.loadBuiltinLibrary("builtin")
 	return i
@@ -42,39 +38,59 @@ func (i *Interpreter) PrintResults(dumpAST bool) {
 }
 
 func (i *Interpreter) PrintErrors(dumpAST bool) {
-	results := i.EvalResults(dumpAST)
+	_, err := i.Eval(dumpAST)
 	if dumpAST {
 		fmt.Printf("\n*** Result ***\n")
 	}
-
-	re, err := regexp.Compile("^\\*\\*\\* ERROR:")
 	if err != nil {
-		panic(err)
+		fmt.Printf("*** ERROR: %s\n%s", err, err.(*SchemeError).Backtrace())
 	}
+}
 
-	for _, result := range results {
-		if re.MatchString(result) {
-			fmt.Println(result)
-		}
+// Eval evaluates source code and returns the value of the last expression,
+// or nil if source code has no expression. If an error is raised without handlers,
+// the evaluation is aborted and the error is returned as *SchemeError with nil result.
+func (i *Interpreter) Eval(dumpAST bool) (result Object, err error) {
+	err = i.evalEach(dumpAST, func(object Object) {
+		result = object
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
 }
 
 func (i *Interpreter) EvalResults(dumpAST bool) (results []string) {
+	err := i.evalEach(dumpAST, func(result Object) {
+		results = append(results, result.String())
+	})
+	if err != nil {
+		results = append(results, fmt.Sprintf("*** ERROR: %s", err))
+	}
+	return results
+}
+
+// Evaluate each expression in source code, and yield its value.
+func (i *Interpreter) evalEach(dumpAST bool, yield func(Object)) (err error) {
 	defer func() {
-		if err := recover(); err != nil {
-			results = append(results, fmt.Sprintf("*** ERROR: %s", err))
+		if r := recover(); r != nil {
+			err = NewSchemeError(r)
 		}
 	}()
 
+	environment := newEvaluation(i.environment)
 	i.Peek()
-	for _, e := range i.Parser.Parse(i.closure) {
+	for _, e := range i.Parser.Parse(nil) {
 		if dumpAST {
 			fmt.Printf("\n*** AST ***\n")
 			i.DumpAST(e, 0)
 		}
-		results = append(results, e.Eval().String())
+		e := e
+		yield(evalRoot(func() Object {
+			return compile(e, NewGlobalScope(i.environment))(environment)
+		}, environment.evaluation, false))
 	}
-	return
+	return nil
 }
 
 // Load new source code with current environment
@@ -82,6 +98,11 @@ func (i *Interpreter) ReloadSourceCode(source string) {
 	i.Parser = NewParser(source)
 }
 
+// Load source code of file with current environment, whose name is shown in errors.
+func (i *Interpreter) ReloadSourceFile(file string, source string) {
+	i.Parser = NewFileParser(file, source)
+}
+
 func (i *Interpreter) DumpAST(object Object, indentLevel int) {
 	if object == nil {
 		return
@@ -118,11 +139,12 @@ func (i *Interpreter) printWithIndent(text string, indentLevel int) {
 func (i *Interpreter) loadBuiltinLibrary(name string) {
 	originalParser := i.Parser
 
-	buffer, err := ioutil.ReadFile(i.libraryPath(name))
+	path := i.libraryPath(name)
+	buffer, err := ioutil.ReadFile(path)
 	if err != nil {
 		log.Fatal(err)
 	}
-	i.Parser = NewParser(string(buffer))
+	i.Parser = NewFileParser(path, string(buffer))
 	i.EvalResults(false)
 
 	i.Parser = originalParser
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -38,20 +39,138 @@ var interpreterTests = []interpreterTest{
 	evalTest("'(  1   2   3  )", "(1 2 3)"),
 	evalTest("'( 1 ( 2 3 ) )", "(1 (2 3))"),
 
-	// evalTest("(quote 12)", "12"),
-	// evalTest("(quote hello)", "hello"),
+	evalTest("(quote 12)", "12"),
+	evalTest("(quote hello)", "hello"),
 	// evalTest("(quote 'hello)", "'hello"),
 	// evalTest("(quote (quote hello))", "'hello"),
-	// evalTest("(quote (cond ()))", "(cond ())"),
-	// evalTest("(quote #f)", "#f"),
-	// evalTest("(quote #t)", "#t"),
-	// evalTest("(quote  ( 1 (3) 4 ))", "(1 (3) 4)"),
+	evalTest("(quote (cond ()))", "(cond ())"),
+	evalTest("(quote #f)", "#f"),
+	evalTest("(quote #t)", "#t"),
+	evalTest("(quote  ( 1 (3) 4 ))", "(1 (3) 4)"),
+	evalTest("(define (f) '(a b)) (eq? (f) (f))", "f", "#t"),
+	evalTest("'(a 'b) '(a `b ,c ,@d) ''a", "(a (quote b))", "(a (quasiquote b) (unquote c) (unquote-splicing d))", "(quote a)"),
+	evalTest("(define-syntax q (syntax-rules () ((_ x) 'x))) (q (a b))", "#<undef>", "(a b)"),
+	evalTest("`(a 'b ,(car '(c)))", "(a (quote b) c)"),
+	evalTest("(let ((x 1)) `'(b ,x))", "(quote (b 1))"),
+	evalTest("(define-macro (show x) `(list ',x '= ,x)) (define y 3) (show y)", "#<undef>", "y", "(y = 3)"),
+	evalTest("(let ((quote list)) (quote 1))", "(1)"),
+	evalTest("(define (f quote) (quote 1)) (f -)", "f", "-1"),
+	evalTest("(let ((quote list) (x 2)) (quote x))", "(2)"),
+	evalTest("(define (f) (quote (a b))) (eq? (f) (f))", "f", "#t"),
+	evalTest("(define-syntax q (syntax-rules () ((_ x) (quote (x quote))))) (q a)", "#<undef>", "(a quote)"),
+	evalTest("(let ((x 3)) `(a (quote ,x)))", "(a (quote 3))"),
+
+	evalTest("`x", "x"),
+	evalTest("`(1 2)", "(1 2)"),
+	evalTest("(define x 5) `(a ,x)", "x", "(a 5)"),
+	evalTest("(define x 5) (quasiquote (a (unquote x)))", "x", "(a 5)"),
+	evalTest("(define l '(1 2)) `(a ,@l b)", "l", "(a 1 2 b)"),
+	evalTest("(define l '(1 2)) `(,@l)", "l", "(1 2)"),
+	evalTest("`(1 ,@'() 2)", "(1 2)"),
+	evalTest("(define x 5) `(1 unquote x)", "x", "(1 . 5)"),
+	evalTest("(define x 5) `((,x) ,(+ x 1))", "x", "((5) 6)"),
+	evalTest("(define x 5) `(a `(b ,(c ,x)))", "x", "(a (quasiquote (b (unquote (c 5)))))"),
+	evalTest("(define x 5) `(a `(b ,,x))", "x", "(a (quasiquote (b (unquote 5))))"),
+	evalTest("'(a `b ,c ,@d)", "(a (quasiquote b) (unquote c) (unquote-splicing d))"),
+	evalTest("`#(1 ,(+ 1 1)) `#(a ,@(list 1 2) b) `#() `(1 #(,(* 2 3)))", "#(1 2)", "#(a 1 2 b)", "#()", "(1 #(6))"),
+	evalTest("`(1 `#(,(+ 1 ,(+ 1 1))))", "(1 (quasiquote #((unquote (+ 1 2)))))"),
+	evalTest("(let ((y 3)) `(y ,y))", "(y 3)"),
 
 	evalTest("\"\"", "\"\""),
 	evalTest("\"hello\"", "\"hello\""),
+	evalTest(`"a\"b\\c"`, `"a\"b\\c"`),
+	evalTest(`"tab\there\nnewline"`, `"tab\there\nnewline"`),
+	evalTest(`"\x41;\x3bb;"`, `"Aλ"`),
+	evalTest("\"first \\\n    second\"", `"first second"`),
+	evalTest("\"multi\nline\"", `"multi\nline"`),
+	evalTest(`"(" ")" ";"`, `"("`, `")"`, `";"`),
+	evalTest(`(string-append "\\" "\"")`, `"\\\""`),
+
+	evalTest(`#\a #\A #\( #\) #\; #\"`, `#\a`, `#\A`, `#\(`, `#\)`, `#\;`, `#\"`),
+	evalTest(`#\space #\newline #\tab #\null #\x41 #\x3bb #\x #\x7f`, `#\space`, `#\newline`, `#\tab`, "#\\null", `#\A`, `#\λ`, `#\x`, `#\delete`),
+	evalTest(`'(#\a #\space "s")`, `(#\a #\space "s")`),
+	evalTest(`(char? #\a) (char? "a") (char->integer #\A) (integer->char 955)`, "#t", "#f", "65", `#\λ`),
+	evalTest(`(char=? #\a #\a #\a) (char<? #\a #\b #\c) (char<? #\a #\c #\b) (char>=? #\b #\b #\a)`, "#t", "#t", "#f", "#t"),
+	evalTest(`(char-ci=? #\a #\A) (char-ci<? #\a #\B) (char=? #\a #\A)`, "#t", "#t", "#f"),
+	evalTest(`(char-alphabetic? #\a) (char-alphabetic? #\1) (char-numeric? #\1) (char-whitespace? #\space) (char-whitespace? #\a)`, "#t", "#f", "#t", "#t", "#f"),
+	evalTest(`(char-upper-case? #\A) (char-lower-case? #\A) (char-upcase #\a) (char-downcase #\A) (char-foldcase #\A)`, "#t", "#f", `#\A`, `#\a`, `#\a`),
+	evalTest(`(digit-value #\7) (digit-value #\a) (digit-value #\x0663)`, "7", "#f", "3"),
+	evalTest(`(eq? #\a #\a) (equal? '(#\a) (list #\a))`, "#t", "#t"),
+
+	evalTest(`(string-length "hello") (string-length "") (string-length "λx日本")`, "5", "0", "4"),
+	evalTest(`(string-ref "hello" 1) (string-ref "λx日本" 2)`, `#\e`, `#\日`),
+	evalTest(`(substring "hello" 1 3) (substring "日本語です" 1 3) (substring "hello" 2)`, `"el"`, `"本語"`, `"llo"`),
+	evalTest(`(string-copy "hello") (string-copy "hello" 3) (string-copy "hello" 1 2)`, `"hello"`, `"lo"`, `"e"`),
+	evalTest(`(define s "abc") (eq? s (string-copy s)) (string=? s (string-copy s))`, "s", "#f", "#t"),
+	evalTest(`(string) (string #\a #\λ) (make-string 3 #\z) (make-string 2)`, `""`, `"aλ"`, `"zzz"`, `"  "`),
+	evalTest(`(string=? "a" "a" "a") (string=? "a" "A") (string<? "a" "b" "c") (string<? "abc" "ab") (string>? "b" "a") (string<=? "a" "a") (string>=? "a" "b")`, "#t", "#f", "#t", "#f", "#t", "#t", "#f"),
+	evalTest(`(string-ci=? "Hello" "hELLO") (string-ci<? "apple" "Banana") (string-ci>? "a" "B") (string-ci<=? "A" "a") (string-ci>=? "a" "B")`, "#t", "#t", "#f", "#t", "#f"),
+	evalTest(`(string->list "aλc") (string->list "hello" 2) (string->list "hello" 1 3) (list->string '(#\a #\λ))`, `(#\a #\λ #\c)`, `(#\l #\l #\o)`, `(#\e #\l)`, `"aλ"`),
+	evalTest(`(string-upcase "Hello, λ") (string-downcase "HeLLo") (string-foldcase "ABC")`, `"HELLO, Λ"`, `"hello"`, `"abc"`),
+	evalTest(`(string-index "hello" #\l) (string-index "hello" #\z) (string-index "日本語" #\語) (string-index "a1b2" char-numeric?) (string-index "hello" #\l 3)`, "2", "#f", "2", "1", "3"),
+	evalTest(`(string-search-forward "lo" "hello lo" 0) (string-search-forward "lo" "hello lo" 4) (string-search-forward "x" "hello" 0) (string-search-forward "語" "日本語日本語" 3)`, "3", "6", "#f", "5"),
+	evalTest(`(string-contains "日本語です" "です") (string-contains "hello" "") (string-contains "hello" "z")`, "3", "0", "#f"),
+	evalTest(`(string-split "a,b,,c" #\,) (string-split "a::b" "::") (string-split "a1b2c" char-numeric?) (string-split "" #\,)`, `("a" "b" "" "c")`, `("a" "b")`, `("a" "b" "c")`, `("")`),
+	evalTest(`(string-join '("a" "b" "c")) (string-join '("a" "b" "c") ", ") (string-join '())`, `"a b c"`, `"a, b, c"`, `""`),
+	evalTest(`(define l '()) (string-for-each (lambda (c) (set! l (cons c l))) "abc") l`, "l", "#<undef>", `(#\c #\b #\a)`),
+	evalTest(`(define l '()) (string-for-each (lambda (a b) (set! l (cons (string a b) l))) "abc" "xy") l`, "l", "#<undef>", `("by" "ax")`),
+	evalTest(`(string-map char-upcase "abc") (string-map (lambda (a b) (if (char<? a b) a b)) "adc" "bbb")`, `"ABC"`, `"abb"`),
+	evalTest(`(let ((k #f) (n 0)) (let ((r (string-map (lambda (c) (call/cc (lambda (c2) (if (char=? c #\b) (set! k c2)) c))) "ab"))) (set! n (+ n 1)) (if (< n 3) (k #\z) (list n r))))`, `(3 "az")`),
+	evalTest(`(define s (make-string 3 #\a)) (string-set! s 1 #\λ) s (string-fill! s #\z) s (string-fill! s #\y 1 2) s`, "s", "#<undef>", `"aλa"`, "#<undef>", `"zzz"`, "#<undef>", `"zyz"`),
+
+	evalTest(`#(1 "a" #\b (c d) #()) '#(1 2)`, `#(1 "a" #\b (c d) #())`, "#(1 2)"),
+	evalTest(`(vector? #(1)) (vector? '(1)) (vector? "a")`, "#t", "#f", "#f"),
+	evalTest(`(vector 1 (+ 1 1) 'c) (vector) (make-vector 2 'a) (vector-length (make-vector 3))`, "#(1 2 c)", "#()", "#(a a)", "3"),
+	evalTest(`(vector-ref #(1 2 3) 0) (vector-ref #(1 2 3) 2) (vector-length #()) (vector-length #(1 #(2 3)))`, "1", "3", "0", "2"),
+	evalTest(`(define v (vector 1 2 3)) (vector-set! v 0 'a) v (vector-fill! v 0) v (vector-fill! v 'z 1 2) v`, "v", "#<undef>", "#(a 2 3)", "#<undef>", "#(0 0 0)", "#<undef>", "#(0 z 0)"),
+	evalTest(`(vector->list #(1 2 3)) (vector->list #(1 2 3) 1) (vector->list #(1 2 3) 1 2) (list->vector '(1 (2))) (list->vector '())`, "(1 2 3)", "(2 3)", "(2)", "#(1 (2))", "#()"),
+	evalTest(`(define a #(1 2 3)) (define b (vector-copy a)) (vector-set! b 0 'x) a b (vector-copy a 1) (vector-copy a 0 2)`, "a", "b", "#<undef>", "#(1 2 3)", "#(x 2 3)", "#(2 3)", "#(1 2)"),
+	evalTest(`(vector-append #(1) #() #(2 3)) (vector-append)`, "#(1 2 3)", "#()"),
+	evalTest(`(vector-map + #(1 2 3) #(10 20)) (vector-map (lambda (x) (* x x)) #(1 2 3))`, "#(11 22)", "#(1 4 9)"),
+	evalTest(`(define l '()) (vector-for-each (lambda (a b) (set! l (cons (+ a b) l))) #(1 2 3) #(4 5 6)) l`, "l", "#<undef>", "(9 7 5)"),
+	evalTest(`(equal? #(1 (2) #\a) (vector 1 '(2) #\a)) (equal? #(1 2) #(1 2 3)) (eq? #(1) #(1)) (equal? '(#(1)) '(#(1)))`, "#t", "#f", "#f", "#t"),
+
+	evalTest(`(define h (make-hash-table)) h (hash-table? h) (hash-table? '())`, "h", "#<hash-table equal?>", "#t", "#f"),
+	evalTest(`(define h (make-hash-table)) (hash-table-set! h "key" 1) (hash-table-set! h (string #\k #\e #\y) 2) (hash-table-set! h '("a" #("b")) 3) (hash-table-ref h "key") (hash-table-ref h (list "a" (vector "b"))) (hash-table-count h)`, "h", "#<undef>", "#<undef>", "#<undef>", "2", "3", "2"),
+	evalTest(`(define h (make-hash-table)) (hash-table-set! h '(1 #(2)) 'a) (hash-table-ref h (list 1 (vector 2))) (hash-table-ref h '(1 #(3)) (lambda () 'none))`, "h", "#<undef>", "a", "none"),
+	evalTest(`(define h (make-hash-table eq?)) (define k '(1)) (hash-table-set! h k 1) (hash-table-ref/default h k 0) (hash-table-ref/default h '(1) 0) (hash-table-ref/default h 2.0 0)`, "h", "k", "#<undef>", "1", "0", "0"),
+	evalTest(`(define h (make-hash-table eqv?)) (hash-table-set! h 1/2 'half) (hash-table-set! h 0.5 'inexact) (hash-table-set! h #\a 'char) (hash-table-ref h (/ 2 4)) (hash-table-ref h 0.5) (hash-table-ref h #\a) (hash-table-count h)`, "h", "#<undef>", "#<undef>", "#<undef>", "half", "inexact", "char", "3"),
+	evalTest(`(define h (make-hash-table string=?)) (hash-table-set! h "key" 1) (hash-table-set! h (string #\k #\e #\y) 2) (hash-table-ref h "key") (hash-table-count h)`, "h", "#<undef>", "#<undef>", "2", "1"),
+	evalTest(`(define h (make-hash-table)) (hash-table-set! h 'a 1) (hash-table-contains? h 'a) (hash-table-delete! h 'a) (hash-table-contains? h 'a) (hash-table-count h) (hash-table-delete! h 'a)`, "h", "#<undef>", "#t", "#<undef>", "#f", "0", "#<undef>"),
+	evalTest(`(define h (make-hash-table)) (hash-table-update! h 'a (lambda (x) (+ x 1)) (lambda () 0)) (hash-table-update! h 'a (lambda (x) (* x 10))) (hash-table-ref h 'a)`, "h", "#<undef>", "#<undef>", "10"),
+	evalTest(`(define h (make-hash-table)) (hash-table-set! h 'a 1) (hash-table-ref h 'a (lambda () 0) (lambda (x) (* x 2))) (hash-table-keys h) (hash-table-values h) (hash-table->alist h)`, "h", "#<undef>", "2", "(a)", "(1)", "((a . 1))"),
+	evalTest(`(define h (make-hash-table)) (define sum 0) (hash-table-set! h 1 10) (hash-table-set! h 2 20) (hash-table-walk h (lambda (k v) (set! sum (+ sum k v)))) sum (length (hash-table->alist h))`, "h", "sum", "#<undef>", "#<undef>", "#<undef>", "33", "2"),
+
+	evalTest("(output-port? (current-output-port)) (input-port? (current-input-port)) (input-port? (current-output-port)) (port? 1) (current-error-port)", "#t", "#t", "#f", "#f", "#<output-port stderr>"),
+	evalTest("(eof-object) (eof-object? (eof-object)) (eof-object? '())", "#<eof>", "#t", "#f"),
+	evalTest(`(define p (open-input-string "x")) (close-port p) (read-char p)`, "p", "#<undef>", "*** ERROR: port is already closed: #<input-port string>"),
+	evalTest(`(define p (open-output-string)) (write-char #\a p) (close-output-port p) (write-char #\b p)`, "p", "#<undef>", "#<undef>", "*** ERROR: port is already closed: #<output-port string>"),
+	evalTest("(close-port (current-output-port)) (newline)", "#<undef>", "#<undef>"),
+	evalTest(`(define p (open-input-string "ab\ncd")) (read-char p) (read-line p) (peek-char p) (read-string 5 p) (read-char p) (read-line (open-input-string ""))`, "p", `#\a`, `"b"`, `#\c`, `"cd"`, "#<eof>", "#<eof>"),
+	evalTest(`(define p (open-output-string)) (write 'a p) (display " " p) (write "b" p) (write-char #\c p) (get-output-string p) (newline p) (get-output-string p)`, "p", "#<undef>", "#<undef>", "#<undef>", "#<undef>", `"a \"b\"c"`, "#<undef>", `"a \"b\"c\n"`),
+	evalTest(`(define p (open-input-string "(a . b) -12 \"s\\n\" #(1 x) 'q #\\a ()")) (read p) (read p) (read p) (read p) (read p) (read p) (read p) (read p)`, "p", "(a . b)", "-12", `"s\n"`, "#(1 x)", "(quote q)", `#\a`, "()", "#<eof>"),
+	evalTest(`(define p (open-input-string "abc def(1 2)x")) (read p) (read-char p) (read p) (read p) (read-char p) (read p)`, "p", "abc", `#\space`, "def", "(1 2)", `#\x`, "#<eof>"),
+	evalTest(`(define p (open-output-string)) (write '(1 (a . 2.5) #\b #(c)) p) (equal? (read (open-input-string (get-output-string p))) '(1 (a . 2.5) #\b #(c)))`, "p", "#<undef>", "#t"),
+	evalTest(`(guard (e ((error-object? e) (list (error-object-kind e) (error-object-message e)))) (read (open-input-string "(a #q)")))`, `(read-error "invalid token: #q")`),
+	evalTest(`(let ((datum (read (open-input-string "(define (f x) (* x 2))")))) (list (car datum) (car (car (cdr datum))) (symbol? (car datum))))`, "(define f #t)"),
+	evalTest(`(with-output-to-string (lambda () (display "x") (write #(1 "y")) (print 'z))) (with-output-to-string (lambda () 1))`, `"x#(1 \"y\")z\n"`, `""`),
+	evalTest(`(call-with-output-string (lambda (port) (display 42 port))) (with-output-to-string (lambda () (display (with-output-to-string (lambda () (display 1)))) (display 2)))`, `"42"`, `"12"`),
+	evalTest(`(define p (open-output-string)) (guard (e (#t (display "!" p))) (with-output-to-string (lambda () (raise 'oops)))) (get-output-string p) (current-output-port)`, "p", "#<undef>", `"!"`, "#<output-port stdout>"),
+	evalTest(`(define k #f) (with-output-to-string (lambda () (display "a") (call/cc (lambda (c) (set! k c))) (display "b"))) (current-output-port)`, "k", `"ab"`, "#<output-port stdout>"),
+	evalTest(`(call/cc (lambda (k) (with-output-to-string (lambda () (k 1))))) (current-output-port)`, "1", "#<output-port stdout>"),
+	evalTest(`(guard (e ((file-error? e) (error-object-message e))) (open-input-file "/nonexistent/file"))`, `"cannot open input file: \"/nonexistent/file\""`),
+
+	evalTest(`(define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)) (define p (make-point 1 2)) p (point? p) (point? '(1 2)) (point-x p) (point-y p) (set-point-x! p 10) (point-x p)`, "<point>", "p", "#<point>", "#t", "#f", "1", "2", "#<undef>", "10"),
+	evalTest(`(define-record-type point (make-point x) point? (x point-x) (y point-y set-point-y!)) (define p (make-point 1)) (set-point-y! p 2) (list (point-x p) (point-y p)) point`, "point", "p", "#<undef>", "(1 2)", "#<record-type point>"),
+	evalTest(`(define-record-type cell make-cell cell? (value cell-value)) (cell-value (make-cell 'a)) cell`, "cell", "a", "#<record-type cell>"),
+	evalTest(`(define-record-type a (make-a x) a? (x a-x)) (define-record-type b (make-a-b x) b? (x b-x)) (a? (make-a-b 1)) (b? (make-a-b 1)) (equal? (make-a 1) (make-a-b 1))`, "a", "b", "#f", "#t", "#f"),
+	evalTest(`(define-record-type a (make-a x) a? (x a-x)) (equal? (make-a '(1 #(2))) (make-a (list 1 (vector 2)))) (eq? (make-a 1) (make-a 1)) (equal? (make-a 1) (make-a 2))`, "a", "#t", "#f", "#f"),
+	evalTest(`(define-record-type a (make-a x) a? (x a-x)) (define h (make-hash-table)) (hash-table-set! h (make-a '(1)) 'found) (hash-table-ref h (make-a '(1))) (hash-table-ref/default (make-hash-table eq?) (make-a '(1)) 'none)`, "a", "h", "#<undef>", "found", "none"),
+	evalTest(`(define (f n) (define-record-type box (make-box v) box? (v unbox)) (unbox (make-box n))) (f 3)`, "f", "3"),
+	evalTest(`(define (make) (define-record-type t (make-t) t?) (cons make-t t?)) (define p (make)) (define q (make)) ((cdr p) ((car q))) ((cdr p) ((car p)))`, "make", "p", "q", "#f", "#t"),
 
 	evalTest("(+)", "0"),
-	evalTest("(- 1)", "1"),
+	evalTest("(- 1)", "-1"),
 	evalTest("(*)", "1"),
 	evalTest("(/ 1)", "1"),
 
@@ -66,6 +185,69 @@ var interpreterTests = []interpreterTest{
 	evalTest("(/ 100(/ 4 2))", "50"),
 	evalTest("(+ (* 100 3) (/(- 4 2) 2))", "301"),
 
+	evalTest("(* 99999999999 99999999999)", "9999999999800000000001"),
+	evalTest("(- (* 99999999999 99999999999) 9999999999800000000000)", "1"),
+	evalTest("(+ 9223372036854775807 1)", "9223372036854775808"),
+	evalTest("(- -9223372036854775808 1)", "-9223372036854775809"),
+	evalTest("(* -1 -9223372036854775808)", "9223372036854775808"),
+	evalTest("123456789012345678901234567890", "123456789012345678901234567890"),
+	evalTest("(/ 7 2)", "7/2"),
+	evalTest("(/ 2)", "1/2"),
+	evalTest("(/ 6 -4)", "-3/2"),
+	evalTest("(+ 1/2 1/3)", "5/6"),
+	evalTest("(* 2/3 3/2)", "1"),
+	evalTest("(/ 1 3 -1/3)", "-1"),
+	evalTest("1.5 -0.25 .5 1. 1e10 1e21 1.5e-10", "1.5", "-0.25", "0.5", "1.0", "10000000000.0", "1.0e21", "1.5e-10"),
+	evalTest("+inf.0 -inf.0 +nan.0", "+inf.0", "-inf.0", "+nan.0"),
+	evalTest("(+ 1 0.5)", "1.5"),
+	evalTest("(* 1/2 4.0)", "2.0"),
+	evalTest("(- 0.5 1/2)", "0.0"),
+	evalTest("(/ 1.0 0)", "+inf.0"),
+	evalTest("(/ 0 0.0)", "+nan.0"),
+	evalTest("1+2i -i 1.5-2.5i 1@0", "1.0+2.0i", "0.0-1.0i", "1.5-2.5i", "1.0"),
+	evalTest("(* +i +i)", "-1.0"),
+	evalTest("(+ 1+2i 1-2i)", "2.0"),
+	evalTest("(/ 1+2i 2)", "0.5+1.0i"),
+	evalTest("#x1F #b-101 #o17 #e1.25 #i3/4 #x#e10", "31", "-5", "15", "5/4", "0.75", "16"),
+	evalTest("(exact 2.5) (exact 2.0) (inexact 1/3) (inexact 10)", "5/2", "2", "0.3333333333333333", "10.0"),
+	evalTest("(exact? 1/2) (exact? 0.5) (inexact? 0.5) (inexact? 1+i)", "#t", "#f", "#t", "#t"),
+	evalTest("(exact->inexact 1/4) (inexact->exact 0.25)", "0.25", "1/4"),
+	evalTest("(integer? 2.0) (integer? 5/2) (rational? 0.5) (rational? +inf.0) (real? 1+i) (complex? 1)", "#t", "#f", "#t", "#f", "#f", "#t"),
+	evalTest("(exact-integer? 2) (exact-integer? 2.0)", "#t", "#f"),
+	evalTest("(real-part 1+2i) (imag-part 1+2i) (imag-part 3) (make-rectangular 1 2) (make-rectangular 1 0)", "1.0", "2.0", "0", "1.0+2.0i", "1"),
+	evalTest("(magnitude -5) (magnitude 3+4i) (angle 1) (make-polar 2 0)", "5", "5.0", "0", "2.0"),
+
+	evalTest("(quotient 17 5) (remainder 17 5) (modulo 17 5)", "3", "2", "2"),
+	evalTest("(quotient -17 5) (remainder -17 5) (modulo -17 5)", "-3", "-2", "3"),
+	evalTest("(quotient 17 -5) (remainder 17 -5) (modulo 17 -5)", "-3", "2", "-3"),
+	evalTest("(modulo -7 2.0) (quotient 7.0 2)", "1.0", "3.0"),
+	evalTest("(modulo 100000000000000000001 10) (quotient -100000000000000000001 10)", "1", "-10000000000000000000"),
+	evalTest("(quotient -9223372036854775808 -1)", "9223372036854775808"),
+	evalTest("(call-with-values (lambda () (floor/ -7 2)) list) (call-with-values (lambda () (truncate/ -7 2)) list)", "(-4 1)", "(-3 -1)"),
+	evalTest("(floor-quotient -7 2) (floor-remainder -7 2) (truncate-quotient -7 2) (truncate-remainder -7 2)", "-4", "1", "-3", "-1"),
+	evalTest("(abs -7) (abs -7/2) (abs -2.5) (abs 3)", "7", "7/2", "2.5", "3"),
+	evalTest("(min 3 1 2) (max 3 1 2) (max 1 2.0) (min 1/2 1/3)", "1", "3", "2.0", "1/3"),
+	evalTest("(max 1 +nan.0 2)", "+nan.0"),
+	evalTest("(gcd 32 -36) (gcd) (gcd 5) (lcm 32 -36) (lcm) (lcm 0 5) (gcd 4.0 6)", "4", "0", "5", "288", "1", "0", "2.0"),
+	evalTest("(expt 2 10) (expt 2 100) (expt 2 -2) (expt 2/3 3) (expt 0 0) (expt 0.0 0)", "1024", "1267650600228229401496703205376", "1/4", "8/27", "1", "1.0"),
+	evalTest("(expt 4 0.5) (expt 2.0 3) (imag-part (expt -1 0.5))", "2.0", "8.0", "1.0"),
+	evalTest("(exp 0) (log 1) (log 100 10) (sin 0) (cos 0) (tan 0) (asin 0) (acos 1) (atan 0) (atan 1 0)", "1.0", "0.0", "2.0", "0.0", "1.0", "0.0", "0.0", "0.0", "0.0", "1.5707963267948966"),
+	evalTest("(log -1) (real-part (asin 2))", "0.0+3.141592653589793i", "1.5707963267948966"),
+	evalTest("(sqrt 16) (sqrt 1/4) (sqrt 2) (sqrt -4) (sqrt 2.25) (sqrt -2.0)", "4", "1/2", "1.4142135623730951", "0.0+2.0i", "1.5", "0.0+1.4142135623730951i"),
+	evalTest("(sqrt 100000000000000000000000000000000000000)", "10000000000000000000"),
+	evalTest("(call-with-values (lambda () (exact-integer-sqrt 17)) list)", "(4 1)"),
+	evalTest("(floor 2.5) (ceiling 2.5) (round 2.5) (round 3.5) (truncate -2.5)", "2.0", "3.0", "2.0", "4.0", "-2.0"),
+	evalTest("(floor -7/2) (ceiling -7/2) (round -7/2) (round 7/2) (round 5/2) (truncate -7/2) (round 8/3)", "-4", "-3", "-4", "4", "2", "-3", "3"),
+	evalTest("(floor 5) (round 1.4)", "5", "1.0"),
+	evalTest("(numerator 6/4) (denominator 6/4) (denominator 5) (numerator 0.5) (denominator 0.5)", "3", "2", "1", "1.0", "2.0"),
+	evalTest("(square 5) (square 1/2) (square 1+i)", "25", "1/4", "0.0+2.0i"),
+	evalTest("(zero? 0) (zero? 0.0) (zero? 1/2) (positive? 1/2) (negative? -0.5) (positive? 0) (negative? +nan.0)", "#t", "#t", "#f", "#t", "#t", "#f", "#f"),
+	evalTest("(odd? 3) (even? 3) (odd? 4.0) (even? 0) (odd? -100000000000000000001)", "#t", "#f", "#f", "#t", "#t"),
+	evalTest("(nan? +nan.0) (nan? 1) (infinite? -inf.0) (infinite? 1.0) (finite? 1/2) (finite? +inf.0)", "#t", "#f", "#t", "#f", "#t", "#f"),
+	evalTest("(number->string 255 16) (number->string -10 2) (number->string 1/3 8) (number->string 1.5)", "\"ff\"", "\"-1010\"", "\"1/3\"", "\"1.5\""),
+	evalTest("(string->number \"ff\" 16) (string->number \"1/2\") (string->number \"1e3\") (string->number \"#b101\") (string->number \"abc\")", "255", "1/2", "1000.0", "5", "#f"),
+	evalTest("(string->number \"101\" 2) (string->number \"12\" 2)", "5", "#f"),
+
 	evalTest("(= 2 1)", "#f"),
 	evalTest("(= (* 100 3) 300)", "#t"),
 
@@ -93,6 +275,16 @@ var interpreterTests = []interpreterTest{
 	evalTest("(>= 3 2 1)", "#t"),
 	evalTest("(>= 1 1 1)", "#t"),
 
+	evalTest("(= 1 1.0 2/2)", "#t"),
+	evalTest("(= 1/3 0.3333333333333333)", "#f"),
+	evalTest("(< 1/3 0.5 1)", "#t"),
+	evalTest("(< 9007199254740992.0 9007199254740993) (= 9007199254740992.0 9007199254740993)", "#t", "#f"),
+	evalTest("(< 1 +inf.0) (> -inf.0 1/2)", "#t", "#f"),
+	evalTest("(= +nan.0 +nan.0) (< 1 +nan.0) (> 1 +nan.0)", "#f", "#f", "#f"),
+	evalTest("(= 1+2i 1+2i) (= 1+2i 1)", "#t", "#f"),
+	evalTest("(eqv? 2 2.0) (eqv? 1/2 (/ 2 4)) (eqv? 100000000000000000000 100000000000000000000) (eqv? 0.0 -0.0)", "#f", "#t", "#t", "#f"),
+	evalTest("(equal? '(1 2.5 1/2) (list 1 2.5 (/ 1 2)))", "#t"),
+
 	evalTest("(not #f)", "#t"),
 	evalTest("(not #t)", "#f"),
 	evalTest("(not (number? ()))", "#t"),
@@ -103,6 +295,13 @@ var interpreterTests = []interpreterTest{
 	evalTest("(car (cons 1 2))", "1"),
 	evalTest("(cons '(1 2) (list 1 2 3))", "((1 2) 1 2 3)"),
 	evalTest("(cons (cons 1 2) 3)", "((1 . 2) . 3)"),
+	evalTest("(cons 1 (cons 2 3))", "(1 2 . 3)"),
+	evalTest("'(1 . 2)", "(1 . 2)"),
+	evalTest("'(a b . c)", "(a b . c)"),
+	evalTest("'(1 . (2 3))", "(1 2 3)"),
+	evalTest("(cdr '(1 . 2))", "2"),
+	evalTest("(cddr '(a b . c))", "c"),
+	evalTest("(define-syntax m (syntax-rules () ((_ a . b) 'b))) (m 1 2 3)", "#<undef>", "(2 3)"),
 	evalTest("(car '(1))", "1"),
 	evalTest("(cdr '(1))", "()"),
 	evalTest("(car '(1 2))", "1"),
@@ -114,6 +313,7 @@ var interpreterTests = []interpreterTest{
 
 	evalTest("(list)", "()"),
 	evalTest("(list 1 2 3)", "(1 2 3)"),
+	evalTest("(list (+ 1 2) 'a)", "(3 a)"),
 	evalTest("(cdr (list 1 2 3))", "(2 3)"),
 
 	evalTest("(length ())", "0"),
@@ -196,7 +396,8 @@ var interpreterTests = []interpreterTest{
 	evalTest("(equal? 1 #f)", "#f"),
 	evalTest("(equal? #f #f)", "#t"),
 	evalTest("(equal? 'foo 'foo)", "#t"),
-	evalTest("(equal? \"foo\" \"foo\")", "#f"),
+	evalTest("(equal? \"foo\" \"foo\")", "#t"),
+	evalTest("(equal? \"foo\" \"bar\") (eq? \"foo\" \"foo\")", "#f", "#f"),
 	evalTest("(equal? '(1 1) '(1 2))", "#f"),
 	evalTest("(equal? '(1 2) '(1 2))", "#t"),
 
@@ -217,6 +418,15 @@ var interpreterTests = []interpreterTest{
 	evalTest("(define x 0) (define y 0) (define z (lambda (z) (set! x y) (set! y z) x)) (z 1) x y (z 2) x y", "x", "y", "z", "0", "0", "1", "1", "1", "2"),
 	evalTest("(define x (lambda (x) (set! y x))) (define y 3) (x 2) y", "x", "y", "2", "2"),
 	evalTest("(define x (lambda (a) (* 2 a))) (define y (lambda (a) (* 3 a))) (define z (lambda (a b) (x a) (y b))) (* (x 3) (y 2) (z 4 5))", "x", "y", "z", "540"),
+	evalTest("((lambda args args) 1 2 3)", "(1 2 3)"),
+	evalTest("((lambda args args))", "()"),
+	evalTest("((lambda (a b . c) (list a b c)) 1 2 3 4)", "(1 2 (3 4))"),
+	evalTest("(define (f . xs) xs) (f) (f 1 2)", "f", "()", "(1 2)"),
+	evalTest("(define (f a . b) (cons a b)) (f 1 2 3)", "f", "(1 2 3)"),
+	evalTest("(define l (list 1 2)) (define (f) ((lambda x (set-car! x 9) x) 1)) (f) l", "l", "f", "(9)", "(1 2)"),
+	evalTest("(define-macro (m) (list 'lambda (cons 'a 'b) (list 'list 'a 'b))) ((m) 1 2 3) ((m) 1)", "#<undef>", "(1 (2 3))", "(1 ())"),
+	evalTest("(define-macro (m) (list 'define (cons 'f 'xs) 'xs)) (m) (f) (f 1 2)", "#<undef>", "f", "()", "(1 2)"),
+	evalTest("(define-macro (m) (list 'define-macro (cons 'n 'xs) (list 'cons ''list 'xs))) (m) (n 1 2)", "#<undef>", "#<undef>", "(1 2)"),
 
 	evalTest("(define x 2) (set! x 3) x", "x", "3", "3"),
 	evalTest("(define x 4) ((lambda (x) (set! x 3) x) 2) x", "x", "3", "4"),
@@ -278,11 +488,71 @@ var interpreterTests = []interpreterTest{
 	evalTest("(letrec ((x 1) (y x)) y)", "*** ERROR: unbound variable: x"),
 	evalTest("(letrec ((x (lambda () x))) (x))", "#<closure x>"),
 
+	evalTest("(letrec* ((x 1) (y (+ x 1))) (list x y))", "(1 2)"),
+	evalTest("(letrec* ((ev? (lambda (n) (if (= n 0) #t (od? (- n 1))))) (od? (lambda (n) (if (= n 0) #f (ev? (- n 1)))))) (ev? 10))", "#t"),
+	evalTest("(letrec* ((x y) (y 1)) x)", "*** ERROR: unbound variable: y"),
+
+	evalTest("(let loop ((i 0) (acc '())) (if (= i 3) acc (loop (+ i 1) (cons i acc))))", "(2 1 0)"),
+	evalTest("(let loop ((i 0)) (if (< i 100000) (loop (+ i 1)) i))", "100000"),
+	evalTest("(let loop () 1)", "1"),
+	evalTest("(let loop ((i 0)) loop)", "#<closure loop>"),
+	evalTest("(define (loop) 'outer) (let loop ((i 0)) i) (loop)", "loop", "0", "outer"),
+	evalTest("(define i 5) (let loop ((i 0) (j i)) j)", "i", "5"),
+
+	evalTest("(values 1)", "1"),
+	evalTest("(values 1 2)", "1 2"),
+	evalTest("(call-with-values (lambda () (values 1 2)) +)", "3"),
+	evalTest("(call-with-values (lambda () 5) list)", "(5)"),
+	evalTest("(let-values (((a b) (values 1 2)) ((c . d) (values 3 4 5)) (e (values))) (list a b c d e))", "(1 2 3 (4 5) ())"),
+	evalTest("(let ((a 1)) (let-values (((a b) (values 2 a))) (list a b)))", "(2 1)"),
+	evalTest("(let*-values (((a b) (values 1 2)) ((c) (values (+ a b)))) (list a b c))", "(1 2 3)"),
+	evalTest("(define-values (q r) (values 7 8)) (list q r)", "#<undef>", "(7 8)"),
+	evalTest("(define-values (h . t) (values 1 2 3)) (list h t)", "#<undef>", "(1 (2 3))"),
+	evalTest("(define-values all (values 1 2)) all", "#<undef>", "(1 2)"),
+	evalTest("(define (f) (define-values (a b) (values 1 2)) (+ a b)) (f)", "f", "3"),
+	evalTest("(let-values (((a b) (values 1))) a)", "*** ERROR: wrong number of values: requires 2, but got 1"),
+	evalTest("(let-values (((a b . c) 1)) a)", "*** ERROR: wrong number of values: requires at least 2, but got 1"),
+
+	evalTest("(define (f) (define (g) (h)) (define (h) 'h) (g)) (f)", "f", "h"),
+	evalTest("(define (f) (define a 1) (define b (+ a 1)) b) (f)", "f", "2"),
+	evalTest("(define (f) (define b (g)) (define (g) 1) b) (f)", "f", "*** ERROR: unbound variable: g"),
+	evalTest("(let () (define x 1) (set! x (+ x 1)) x)", "2"),
+
 	evalTest("(actor)", "#<actor #f>"),
 	evalTest("(actor ((\"hello\") \"hello\"))", "#<actor #f>"),
 	evalTest("(define master (actor)) master", "master", "#<actor master>"),
 
 	evalTest("(define-macro (positive x) (list '> x 0)) positive", "#<undef>", "#<macro positive>"),
+	evalTest("(define-macro (positive x) (list '> x 0)) (positive 3) (positive -3)", "#<undef>", "#t", "#f"),
+	evalTest("(define-macro (positive x) (list '> x 0)) (define (f x) (positive x)) (f 2)", "#<undef>", "f", "#t"),
+	evalTest("(define-macro (inc! x) (list 'set! x (list '+ x 1))) (define y 1) (inc! y) y", "#<undef>", "y", "2", "2"),
+	evalTest("(define-macro inc! (lambda (x) (list 'set! x (list '+ x 1)))) (define y 1) (inc! y)", "#<undef>", "y", "2"),
+	evalTest("(define-macro (my-quote x) (list 'quote x)) (my-quote (a b))", "#<undef>", "(a b)"),
+	evalTest("(define-macro (positive x) (list '> x 0)) (macroexpand-1 '(positive 3))", "#<undef>", "(> 3 0)"),
+	evalTest("(define-macro (when2 c x) (list 'if c x)) (define-macro (when3 x) (list 'when2 #t x)) (macroexpand-1 '(when3 1)) (macroexpand '(when3 1))", "#<undef>", "#<undef>", "(when2 #t 1)", "(if #t 1)"),
+	evalTest("(macroexpand '(+ 1 2)) (macroexpand 1)", "(+ 1 2)", "1"),
+
+	evalTest("(define-syntax my-or (syntax-rules () ((_) #f) ((_ e) e) ((_ e r ...) (let ((t e)) (if t t (my-or r ...)))))) (my-or) (my-or #f 3 #f)", "#<undef>", "#f", "3"),
+	evalTest("(define-syntax my-or (syntax-rules () ((_ e r) (let ((t e)) (if t t r))))) (define t 5) (my-or #f t)", "#<undef>", "t", "5"),
+	evalTest("(define-syntax my-or (syntax-rules () ((_ e r) (let ((t e)) (if t t r))))) (let ((if list)) (my-or #f 7))", "#<undef>", "7"),
+	evalTest("(define-syntax swap! (syntax-rules () ((_ a b) (let ((tmp a)) (set! a b) (set! b tmp))))) (define tmp 1) (define y 2) (swap! tmp y) tmp y", "#<undef>", "tmp", "y", "1", "2", "1"),
+	evalTest("(define n 0) (define-syntax bump! (syntax-rules () ((_) (set! n (+ n 1))))) (let ((n 10)) (bump!) n) n", "n", "#<undef>", "10", "1"),
+	evalTest("(define-syntax my-let (syntax-rules () ((_ ((n v) ...) body ...) ((lambda (n ...) body ...) v ...)))) (my-let ((a 1) (b 2)) (+ a b))", "#<undef>", "3"),
+	evalTest("(define-syntax flat (syntax-rules () ((_ (a b ...) ...) '(a ... b ... ...)))) (flat (1 2 3) (4 5))", "#<undef>", "(1 4 2 3 5)"),
+	evalTest("(define-syntax my-cond (syntax-rules (else) ((_ (else e)) e) ((_ (c e) r ...) (if c e (my-cond r ...))))) (my-cond (#f 1) (else 2))", "#<undef>", "2"),
+	evalTest("(define-syntax kw (syntax-rules (=>) ((_ a => b) 'literal) ((_ a b c) 'variable))) (kw 1 => 2) (let ((=> 0)) (kw 1 => 2))", "#<undef>", "literal", "variable"),
+	evalTest("(define-syntax kw (syntax-rules (=>) ((_ a => b) 'literal) ((_ a b c) 'variable))) (define-syntax use-kw (syntax-rules () ((_ a) (kw a => 2)))) (let ((=> 0)) (use-kw 1))", "#<undef>", "#<undef>", "literal"),
+	evalTest("(let ((=> 0)) (let-syntax ((kw (syntax-rules (=>) ((_ =>) 'literal) ((_ a) 'variable)))) (list (kw =>) (let ((=> 1)) (kw =>)))))", "(literal variable)"),
+	evalTest("(define-syntax last-of (syntax-rules () ((_ a ... b) 'b))) (last-of 1 2 3)", "#<undef>", "3"),
+	evalTest("(define-syntax vsum (syntax-rules () ((_ #(a ...)) (+ a ...)))) (vsum #(1 2 3)) (vsum #())", "#<undef>", "6", "0"),
+	evalTest("(define-syntax v? (syntax-rules () ((_ #(a)) 'vector) ((_ a) 'other))) (v? #(1)) (v? (1)) (v? #(1 2))", "#<undef>", "vector", "other", "other"),
+	evalTest("(define-syntax rev (syntax-rules () ((_ #(a b)) '#(b a c)))) (rev #(1 2)) (eq? (vector-ref (rev #(1 2)) 2) 'c)", "#<undef>", "#(2 1 c)", "#t"),
+	evalTest("(define-syntax my-begin (syntax-rules ::: () ((_ e :::) ((lambda () e :::))))) (my-begin 1 2)", "#<undef>", "2"),
+	evalTest("(define-syntax dots (syntax-rules () ((_ a) '(a (... ...))))) (dots 1)", "#<undef>", "(1 ...)"),
+	evalTest("(define-syntax swap! (syntax-rules () ((_ a b) (let ((tmp a)) (set! a b) (set! b tmp))))) (macroexpand '(swap! x y))", "#<undef>", "(let ((tmp x)) (set! x y) (set! y tmp))"),
+	evalTest("(let-syntax ((double (syntax-rules () ((_ x) (* x 2))))) (double 21))", "42"),
+	evalTest("(define-syntax foo (syntax-rules () ((_) 'outer))) (let-syntax ((foo (syntax-rules () ((_) 'inner))) (bar (syntax-rules () ((_) (foo))))) (bar))", "#<undef>", "outer"),
+	evalTest("(letrec-syntax ((ev? (syntax-rules () ((_) #t) ((_ x r ...) (od? r ...)))) (od? (syntax-rules () ((_) #f) ((_ x r ...) (ev? r ...))))) (ev? 1 2 3 4))", "#t"),
 
 	evalTest("actor", "#<syntax actor>"),
 	evalTest("set!", "#<syntax set!>"),
@@ -301,6 +571,81 @@ var interpreterTests = []interpreterTest{
 	// Tail Call Optimization
 	evalTest("(letrec ((rec (lambda (x) (if (= x 0) #t (rec (- x 1)))))) (rec 1))", "#t"),
 	evalTest(`(define (even? x) (if (= x 0) #t (odd? (- x 1)))) (define (odd? x) (if (= x 1) #t (even? (- x 1)))) (even? 10)`, "even?", "odd?"),
+	evalTest("(define (f x) (if (= x 0) 'done (f (- x 1)))) (f 3)", "f", "done"),
+	evalTest("(define (f x) (cond ((= x 0) 'done) (else (f (- x 1))))) (f 3)", "f", "done"),
+	evalTest("(define (f x) (when (> x 0) (unless #f (f (- x 1))))) (f 3)", "f", "#<undef>"),
+
+	evalTest("(when #t 1 2)", "2"),
+	evalTest("(when #f 1)", "#<undef>"),
+	evalTest("(unless #f 1 2)", "2"),
+	evalTest("(unless #t 1)", "#<undef>"),
+
+	// Environment frames
+	evalTest("(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2))))) (fib 10)", "fib", "55"),
+	evalTest("(define (f n) (if (= n 0) 0 (begin (f (- n 1)) n))) (f 5)", "f", "5"),
+	evalTest("(define (make-counter) (let ((c 0)) (lambda () (set! c (+ c 1)) c))) (define a (make-counter)) (define b (make-counter)) (a) (a) (b)", "make-counter", "a", "b", "1", "2", "1"),
+	evalTest("(define (map1 f l) (if (null? l) '() (cons (f (car l)) (map1 f (cdr l))))) (map1 (lambda (x) (map1 (lambda (y) (* x y)) '(1 2))) '(1 2))", "map1", "((1 2) (2 4))"),
+	evalTest("(define (f x) (lambda () x)) (define g (f 1)) (f 2) (g)", "f", "g", "#<closure #f>", "1"),
+	evalTest("(define (f) (let () (define (a) (b)) (define (b) 1) (a))) (f)", "f", "1"),
+	evalTest("(define (f x) (let ((x (* x 2))) (set! x (+ x 1)) x)) (f 3)", "f", "7"),
+	evalTest("(define (f) (m 2)) (define-macro (m x) `(* ,x 3)) (f)", "f", "#<undef>", "6"),
+	evalTest("(define (f y) (let-syntax ((add (syntax-rules () ((_ x) (+ x y))))) (add 1))) (f 10)", "f", "11"),
+	evalTest("(define closures '()) (do ((i 0 (+ i 1))) ((= i 2)) (set! closures (cons (lambda () i) closures))) ((car closures)) ((car (cdr closures)))", "closures", "#t", "1", "0"),
+	evalTest("(let* ((x 1) (x (+ x 1))) x)", "2"),
+
+	// Continuations
+	evalTest("(call/cc (lambda (k) 5))", "5"),
+	evalTest("(+ 1 (call/cc (lambda (k) (+ 10 (k 1)))))", "2"),
+	evalTest("(call/cc (lambda (k) k)) (procedure? (call/cc (lambda (k) k)))", "#<continuation>", "#t"),
+	evalTest("(call-with-values (lambda () (call/cc (lambda (k) (k 1 2)))) list)", "(1 2)"),
+	evalTest("(define (f n k) (if (= n 0) (k 'done) (+ 1 (f (- n 1) k)))) (call-with-current-continuation (lambda (k) (f 1000 k)))", "f", "done"),
+	evalTest("(define (find p l) (call/ec (lambda (return) (let loop ((l l)) (if (pair? l) (begin (if (p (car l)) (return (car l))) (loop (cdr l))) #f))))) (find (lambda (x) (> x 2)) '(1 2 3 4))", "find", "3"),
+	evalTest("(define r #f) (+ 1 (call/cc (lambda (k) (set! r k) 1))) (r 5)", "r", "2", "6"),
+	evalTest("(define k #f) (define (f n) (if (= n 0) (call/cc (lambda (c) (set! k c) 0)) (+ 1 (f (- n 1))))) (f 100) (k 10)", "k", "f", "100", "110"),
+	evalTest("(let ((n 0) (k #f)) (let ((x (call/cc (lambda (c) (set! k c) 0)))) (set! n (+ n 1)) (if (< x 3) (k (+ x 1)) (list x n))))", "(3 4)"),
+	evalTest("(let ((k #f) (n 0)) (let ((l (list 1 (call/cc (lambda (c) (set! k c) 2)) 3))) (set! n (+ n 1)) (if (= n 1) (k 20) (list l n))))", "((1 20 3) 2)"),
+	evalTest("(let ((k #f) (n 0)) (let ((r (do ((i 0 (+ i 1)) (l '() (cons (call/cc (lambda (c) (if (= i 1) (set! k c)) i)) l))) ((= i 3) l)))) (set! n (+ n 1)) (if (< n 3) (k (* n 10)) r)))", "(2 20 0)"),
+	evalTest("(let ((n 0) (k #f)) (if (and (call/cc (lambda (c) (set! k c) #t)) (begin (set! n (+ n 1)) (< n 3))) (k #t) n))", "3"),
+	evalTest("(let ((k #f) (n 0)) (let ((v `(a ,(call/cc (lambda (c) (set! k c) 1)) b))) (set! n (+ n 1)) (if (= n 1) (k 2) v)))", "(a 2 b)"),
+	evalTest("(define (gen) (define k #f) (define n 0) (call/cc (lambda (c) (set! k c))) (set! n (+ n 1)) (if (< n 5) (k #f) n)) (gen)", "gen", "5"),
+	evalTest("(let loop ((i 0)) (if (< i 1000) (begin (call/cc (lambda (k) k)) (loop (+ i 1))) i))", "1000"),
+	evalTest("(call/ec (lambda (k) (call/cc (lambda (c) (k 7))))) (call/cc (lambda (k) (call/ec (lambda (c) (k 8)))))", "7", "8"),
+	evalTest("(define s #f) (call/ec (lambda (e) (+ 1 (call/cc (lambda (c) (set! s c) 1))))) (s 10)", "s", "2", "11"),
+
+	// Exceptions
+	evalTest("(guard (e (#t (list 'caught e))) (raise 'oops))", "(caught oops)"),
+	evalTest("(guard (e ((symbol? e) 'symbol) ((string? e) 'string)) (raise \"oops\"))", "string"),
+	evalTest("(guard (e ((string? e) 'string) (else 'other)) (+ 1 (raise 2)))", "other"),
+	evalTest("(guard (e (#f 'never)) 10)", "10"),
+	evalTest("(guard (e ((number? e) (* e 2))) (guard (e ((string? e) 'inner)) (raise 21)))", "42"),
+	evalTest("(guard (e ((error-object? e) (list (error-object-message e) (error-object-irritants e)))) (error \"bad thing\" 1 'two))", "(\"bad thing\" (1 two))"),
+	evalTest("(guard (e ((error-object? e) (error-object-kind e))) (car 1))", "wrong-type-argument"),
+	evalTest("(guard (e ((error-object? e) (error-object-message e))) undefined-variable)", "\"unbound variable: undefined-variable\""),
+	evalTest("(guard (e ((error-object? e) (error-object-message e))) (car 1)) (guard (e (#t (error-object-message e))) ((lambda (x) x)))", "\"pair required, but got 1\"", "\"wrong number of arguments: requires 1, but got 0\""),
+	evalTest("(guard (e ((error-object? e) (error-object-kind e))) ((lambda (x) x)))", "wrong-number-of-arguments"),
+	evalTest("(guard (e (#t (error-object-irritants e))) (last '()))", "(())"),
+	evalTest("(define (kind thunk) (guard (e ((error-object? e) (error-object-kind e))) (thunk))) (list (kind (lambda () (1 2))) (kind (lambda () (set! undefined-variable 1))) (kind (lambda () (let-values (((a b) (values 1))) a))) (kind (lambda () `(1 ,@2))))", "kind", "(invalid-application runtime-error runtime-error runtime-error)"),
+	evalTest("(define (safe-div a b) (guard (e (#t 'error)) (if (= b 0) (raise 'zero) (/ a b)))) (list (safe-div 6 3) (safe-div 1 0))", "safe-div", "(2 error)"),
+	evalTest("(with-exception-handler (lambda (e) 10) (lambda () (+ 1 (raise-continuable 'c))))", "11"),
+	evalTest("(with-exception-handler (lambda (e) (* e 2)) (lambda () (with-exception-handler (lambda (e) (+ (raise-continuable e) 1)) (lambda () (raise-continuable 5)))))", "11"),
+	evalTest("(call/cc (lambda (k) (with-exception-handler (lambda (e) (k (list 'handled e))) (lambda () (raise 'boom)))))", "(handled boom)"),
+	evalTest("(guard (e (#t (error-object-kind e))) (with-exception-handler (lambda (e) 0) (lambda () (raise 'boom))))", "handler-returned"),
+	evalTest("(guard (e ((eq? e 'outer) 'outer)) (with-exception-handler (lambda (e) (raise 'outer)) (lambda () (raise 'inner))))", "outer"),
+	evalTest("(let loop ((i 0)) (if (< i 1000) (loop (guard (e (#t (+ i 1))) (raise i))) i))", "1000"),
+	evalTest("(let ((k #f) (n 0)) (guard (e (#t e)) (call/cc (lambda (c) (set! k c))) (set! n (+ n 1))) (if (< n 3) (k #f) n))", "3"),
+	evalTest("(let ((k #f) (n 0) (caught #f)) (guard (e (#t (set! caught e))) (call/cc (lambda (c) (set! k c))) (set! n (+ n 1)) (if (= n 2) (raise 'again))) (if (< n 2) (k #f) (list caught n)))", "(again 2)"),
+	evalTest("(guard (e (#t (raise 'again))) 1) (error-object? (guard (e (#t e)) (error \"x\")))", "1", "#t"),
+	evalTest("(with-exception-handler (lambda (e) 0) (lambda () (guard (e ((string? e) 's)) (+ 1 (raise-continuable 'c)))))", "1"),
+	evalTest("(guard (e (#t (error-object-kind e))) (with-exception-handler (lambda (e) 0) (lambda () (guard (e (#f 1)) (raise 'x)))))", "handler-returned"),
+	evalTest("(guard (e ((memq 'a e) => cdr) ((memq 'b e))) (raise '(a 42)))", "(42)"),
+	evalTest("(guard (e ((memq 'a e) => cdr) ((memq 'b e))) (raise '(b 23)))", "(b 23)"),
+	evalTest("(cond ((memq 2 '(1 2 3)) => cdr) (else 'none)) (cond (#f => car))", "(3)", "#<undef>"),
+
+	// Backtraces
+	evalTest("(current-backtrace)", "((current-backtrace \"1:1\" ()))"),
+	evalTest("(define (f x) (g (+ x 1) 'a)) (define (g y z) (list (current-backtrace))) (f 1)", "f", "g", "(((current-backtrace \"1:53\" ()) (g \"1:15\" (2 a))))"),
+	evalTest("(define (f x) (list (g x) 1)) (define (g y) (current-backtrace)) (f 1)", "f", "g", "(((current-backtrace \"1:45\" ()) (f \"1:66\" (1))) 1)"),
+	evalTest("(call-with-values (lambda () (current-backtrace)) list)", "(((current-backtrace \"1:30\" ()) (call-with-values \"1:1\" (#<closure #f> #<subr list>))))"),
 }
 
 var runtimeErrorTests = []interpreterTest{
@@ -310,6 +655,33 @@ var runtimeErrorTests = []interpreterTest{
 	// evalTest("'1'", "1", "*** ERROR: unterminated quote"),
 	evalTest("(last ())", "*** ERROR: pair required: ()"),
 	evalTest("((lambda (x) (set! x 3) x) 2) x", "3", "*** ERROR: unbound variable: x"),
+	evalTest("(define e #f) (call/ec (lambda (k) (set! e k) 1)) (e 2)", "e", "1", "*** ERROR: continuation is no longer available"),
+	evalTest("(raise 'oops)", "*** ERROR: unhandled exception: oops"),
+	evalTest("(/ 1 0)", "*** ERROR: attempt to divide by zero"),
+	evalTest(`(string-ref "abc" 3)`, "*** ERROR: index out of range: 3"),
+	evalTest(`(substring "abc" 2 1)`, "*** ERROR: index out of range: 2"),
+	evalTest("(vector-ref #(1 2) 2)", "*** ERROR: index out of range: 2"),
+	evalTest("(vector-copy #(1 2) 3)", "*** ERROR: index out of range: 3"),
+	evalTest(`(open-output-file "/nonexistent/file")`, `*** ERROR: cannot open output file: "/nonexistent/file"`),
+	evalTest("(hash-table-ref (make-hash-table) 'a)", "*** ERROR: hash table doesn't have an entry for key: a"),
+	evalTest("(make-hash-table (lambda (a b) #t))", "*** ERROR: unsupported equality for hash table: #<closure #f>"),
+	evalTest("(hash-table-set! (make-hash-table string=?) 'a 1)", "*** ERROR: Compile Error: string required, but got a"),
+	evalTest("(read-char (current-output-port))", "*** ERROR: Compile Error: input port required, but got #<output-port stdout>"),
+	evalTest("(newline 1)", "*** ERROR: Compile Error: output port required, but got 1"),
+	evalTest(`(read (open-input-string "(1 2"))`, "*** ERROR: syntax error"),
+	evalTest(`(read (open-input-string ")"))`, "*** ERROR: syntax error"),
+	evalTest(`(get-output-string (open-input-string "a"))`, "*** ERROR: Compile Error: string output port required, but got #<input-port string>"),
+	evalTest("(define-record-type a (make-a x) a? (x a-x)) (define-record-type b (make-b x) b? (x b-x)) (a-x (make-b 1))", "a", "b", "*** ERROR: Compile Error: a required, but got #<b>"),
+	evalTest("(define-record-type a (make-a x) a? (x a-x)) (make-a)", "a", "*** ERROR: Compile Error: wrong number of arguments: requires 1, but got 0"),
+	evalTest(`(guard (e ((error-object? e) (error-object-kind e))) (string-ref "" 0))`, "out-of-range"),
+	evalTest("(guard (e (#t (error-object-kind e))) (/ 5 0))", "division-by-zero"),
+	evalTest("(modulo 5 0)", "*** ERROR: attempt to divide by zero"),
+	evalTest("(guard (e ((error-object? e) (error-object-message e))) (quotient 5 0))", "\"attempt to divide by zero\""),
+	evalTest("(expt 0 -1)", "*** ERROR: attempt to divide by zero"),
+	evalTest("(number->string 1.5 2)", "*** ERROR: inexact number cannot be written in radix 2: 1.5"),
+	evalTest("(error \"something bad:\" 1 \"two\")", "*** ERROR: something bad: 1 \"two\""),
+	evalTest("(guard (e ((string? e) 'string)) (car 1))", "*** ERROR: Compile Error: pair required, but got 1"),
+	evalTest("(with-exception-handler (lambda (e) 0) (lambda () (raise 'boom)))", "*** ERROR: exception handler returned from non-continuable raise: boom"),
 
 	evalTest("(define set! 0) (set! define 0)", "set!", "*** ERROR: invalid application"),
 	evalTest("(define if 0) (if #t 0)", "if", "*** ERROR: invalid application"),
@@ -319,9 +691,27 @@ var runtimeErrorTests = []interpreterTest{
 var compileErrorTests = []interpreterTest{
 	evalTest("(quote)", "*** ERROR: Compile Error: syntax-error: malformed quote: (quote)"),
 	evalTest("(define)", "*** ERROR: Compile Error: syntax-error: malformed define: (define)"),
-
-	evalTest("(-)", "*** ERROR: Compile Error: procedure requires at least 1 argument"),
-	evalTest("(/)", "*** ERROR: Compile Error: procedure requires at least 1 argument"),
+	evalTest("(define-record-type a (make-a x))", "*** ERROR: Compile Error: syntax-error: malformed define-record-type: (define-record-type a (make-a x))"),
+	evalTest("(define-record-type a (make-a y) a? (x a-x))", "*** ERROR: Compile Error: syntax-error: unknown field y in (define-record-type a (make-a y) a? (x a-x))"),
+
+	evalTest("(-)", "*** ERROR: Compile Error: wrong number of arguments: requires at least 1, but got 0"),
+	evalTest("((lambda (x) x))", "*** ERROR: Compile Error: wrong number of arguments: requires 1, but got 0"),
+	evalTest("(define-macro (m) (list 'lambda (cons 'a 'b) 'a)) ((m))", "#<undef>", "*** ERROR: Compile Error: wrong number of arguments: requires at least 1, but got 0"),
+	evalTest("(lambda (1) 1)", "*** ERROR: Compile Error: syntax-error: malformed lambda: (lambda (1) 1)"),
+	evalTest("(lambda (x . 1) 1)", "*** ERROR: Compile Error: syntax-error: malformed lambda: (lambda (x . 1) 1)"),
+	evalTest("((lambda (a . b) b))", "*** ERROR: Compile Error: wrong number of arguments: requires at least 1, but got 0"),
+	evalTest("(+ 1 . 2)", "*** ERROR: Compile Error: proper list required for function application or macro use"),
+	evalTest("(. 1)", "*** ERROR: syntax error: bad dot syntax"),
+	evalTest(`#\foo`, `*** ERROR: invalid character name: #\foo`),
+	evalTest(`"\q"`, `*** ERROR: invalid escape sequence in string: "\q"`),
+	evalTest(`"\x41"`, `*** ERROR: invalid hex escape in string: "\x41"`),
+	evalTest(`(integer->char -1)`, "*** ERROR: Compile Error: code point required, but got -1"),
+	evalTest(`(char->integer "a")`, `*** ERROR: Compile Error: char required, but got "a"`),
+	evalTest("'(. 1)", "*** ERROR: syntax error: bad dot syntax"),
+	evalTest("'(1 . 2 3)", "*** ERROR: syntax error: bad dot syntax"),
+	evalTest("'(1 .)", "*** ERROR: syntax error: bad dot syntax"),
+	evalTest("(1 . 2 3)", "*** ERROR: syntax error: bad dot syntax"),
+	evalTest("(/)", "*** ERROR: Compile Error: wrong number of arguments: requires at least 1, but got 0"),
 	evalTest("(number?)", "*** ERROR: Compile Error: wrong number of arguments: requires 1, but got 0"),
 	evalTest("(null?)", "*** ERROR: Compile Error: wrong number of arguments: requires 1, but got 0"),
 	evalTest("(null? 1 2)", "*** ERROR: Compile Error: wrong number of arguments: requires 1, but got 2"),
@@ -331,9 +721,22 @@ var compileErrorTests = []interpreterTest{
 	evalTest("(- #t)", "*** ERROR: Compile Error: number required, but got #t"),
 	evalTest("(* ())", "*** ERROR: Compile Error: number required, but got ()"),
 	evalTest("(/ '(1 2 3))", "*** ERROR: Compile Error: number required, but got (1 2 3)"),
+	evalTest("(< 1 +i)", "*** ERROR: Compile Error: real number required, but got 0.0+1.0i"),
+	evalTest("(exact +inf.0)", "*** ERROR: Compile Error: finite number required, but got +inf.0"),
+	evalTest("(quotient 1/2 1)", "*** ERROR: Compile Error: integer required, but got 1/2"),
+	evalTest("(odd? 1.5)", "*** ERROR: Compile Error: integer required, but got 1.5"),
+	evalTest("(floor 1+i)", "*** ERROR: Compile Error: real number required, but got 1.0+1.0i"),
+	evalTest("(exact-integer-sqrt -1)", "*** ERROR: Compile Error: exact non-negative integer required, but got -1"),
+	evalTest("(number->string 1 3)", "*** ERROR: Compile Error: radix must be 2, 8, 10 or 16, but got 3"),
+	evalTest("(log 1 2 3)", "*** ERROR: Compile Error: wrong number of arguments: requires 1 to 2, but got 3"),
 
 	evalTest("(string-append #f)", "*** ERROR: Compile Error: string required, but got #f"),
 	evalTest("(string-append 1)", "*** ERROR: Compile Error: string required, but got 1"),
+	evalTest(`(string-length 'a)`, "*** ERROR: Compile Error: string required, but got a"),
+	evalTest(`(vector-ref '(1) 0)`, "*** ERROR: Compile Error: vector required, but got (1)"),
+	evalTest(`(vector-append #(1) "a")`, "*** ERROR: Compile Error: vector required, but got \"a\""),
+	evalTest(`(list->string '(#\a "b"))`, `*** ERROR: Compile Error: char required, but got "b"`),
+	evalTest(`(string-index "abc" 1)`, "*** ERROR: Compile Error: procedure required, but got 1"),
 
 	evalTest("(string->symbol)", "*** ERROR: Compile Error: wrong number of arguments: requires 1, but got 0"),
 	evalTest("(string->symbol 'hello)", "*** ERROR: Compile Error: string required, but got hello"),
@@ -363,6 +766,15 @@ var compileErrorTests = []interpreterTest{
 	evalTest("(let ((x 1 1)))", "*** ERROR: Compile Error: syntax-error: malformed let: (let ((x 1 1)))"),
 
 	evalTest("(actor ())", "*** ERROR: Compile Error: syntax-error: malformed actor: (actor ())"),
+	evalTest("(define-macro (m x))", "*** ERROR: Compile Error: syntax-error: malformed define-macro: (define-macro (m x))"),
+	evalTest("(define-macro m 1)", "*** ERROR: Compile Error: closure required, but got 1"),
+	evalTest("(define-syntax m (syntax-rules () ((_ x) x))) (m)", "#<undef>", "*** ERROR: Compile Error: syntax-error: no matching syntax rule: (m)"),
+	evalTest("(define-syntax m (syntax-rules () ((_ x ...) x))) (m 1)", "#<undef>", "*** ERROR: Compile Error: syntax-error: pattern variable used without ellipsis: x"),
+	evalTest("(define-syntax m 1)", "*** ERROR: Compile Error: syntax-error: transformer required, but got 1"),
+	evalTest("(syntax-rules (1))", "*** ERROR: Compile Error: syntax-error: malformed syntax-rules: (syntax-rules (1))"),
+
+	evalTest("(define x 1) ,x", "x", "*** ERROR: Compile Error: syntax-error: unquote appeared outside quasiquote: ,x"),
+	evalTest("(define l '(1)) `,@l", "l", "*** ERROR: Compile Error: syntax-error: unquote-splicing appeared outside list: ,@l"),
 }
 
 func evalTest(source string, results ...string) interpreterTest {
@@ -412,3 +824,293 @@ func TestLoad(t *testing.T) {
 		}
 	}
 }
+
+func TestPorts(t *testing.T) {
+	file, err := ioutil.TempFile(os.TempDir(), "port_test")
+	if err != nil {
+		panic(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	source := strings.Replace(`
+		(call-with-output-file "FILE" (lambda (port) (write-string "hello" port) (write-char #\space port) (display '(1 "a") port) (newline port) (write "q" port) 'done))
+		(call-with-input-file "FILE" read-line)
+		(define p (open-input-file "FILE"))
+		(read-char p) (peek-char p) (read-string 4 p) (read-line p) (read-line p) (read-char p) (read-line p)
+		(with-output-to-file "FILE" (lambda () (display "x") (print 1) (write-string "abc" (current-output-port) 1 2) 'ok))
+		(with-input-from-file "FILE" (lambda () (read-string 10)))
+		(call-with-input-file "FILE" read)
+		(close-port p)
+		(read-char p)`, "FILE", file.Name(), -1)
+	interpreter := NewInterpreter(source)
+	expects := []string{"done", `"hello (1 a)"`, "p", `#\h`, `#\e`, `"ello"`, `" (1 a)"`, `"\"q\""`, "#<eof>", "#<eof>", "ok", `"x1\nb"`, "x1", "#<undef>",
+		fmt.Sprintf("*** ERROR: port is already closed: #<input-port %s>", file.Name())}
+	actuals := interpreter.EvalResults(false)
+
+	if len(actuals) != len(expects) {
+		t.Fatalf("%s => %v; want %v", source, actuals, expects)
+	}
+	for i := 0; i < len(actuals); i++ {
+		if actuals[i] != expects[i] {
+			t.Errorf("%s => %s; want %s", source, actuals[i], expects[i])
+		}
+	}
+}
+
+func TestLongSource(t *testing.T) {
+	// source which is longer than the buffer of scanner
+	source := strings.Repeat("(define abcdefghij 12345)\n", 100) + "abcdefghij"
+	results := NewInterpreter(source).EvalResults(false)
+	if actual := results[len(results)-1]; len(results) != 101 || actual != "12345" {
+		t.Errorf("%s => %s; want 12345", source, actual)
+	}
+}
+
+func TestBacktrace(t *testing.T) {
+	source := "(define (f x) (if (= x 0) (car x) (+ 1 (f (- x 1)))))\n(f 2)"
+	_, err := NewInterpreter(source).Eval(false)
+	expect := "Stack Trace:\n  0  (car 0) at 1:27\n  1  (f 1) at 1:40\n  2  (f 2) at 2:1\n"
+	if backtrace := err.(*SchemeError).Backtrace(); backtrace != expect {
+		t.Errorf("%s => %q; want %q", source, backtrace, expect)
+	}
+
+	frames := err.(*SchemeError).Frames
+	if len(frames) != 3 || frames[2].Name() != "f" || frames[2].Arguments[0].String() != "2" {
+		t.Errorf("%s => %v; want frames of car, f and f", source, frames)
+	}
+}
+
+func TestErrorLocation(t *testing.T) {
+	file, err := ioutil.TempFile(os.TempDir(), "location_test")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(file.Name())
+
+	tests := []struct {
+		source string
+		result string
+	}{
+		{"(define x 1)\n  (car x)", "2:3: Compile Error: pair required, but got 1"},
+		{"(define (f x)\n  (+ 1\n     (car x)))\n(f 2)", "3:6: Compile Error: pair required, but got 2"},
+		{"(define (f x)\n  (cdr x))\n(f 2)", "2:3: Compile Error: pair required, but got 2"},
+		{"(print\n  foo)", "2:3: unbound variable: foo"},
+		{"(list 1\n  (foo 2))", "2:4: unbound variable: foo"},
+		{"\n  foo", "2:3: unbound variable: foo"},
+		{"(car\n '(a . b)\n bar)", "3:2: unbound variable: bar"},
+		{"\n (if)", "2:2: Compile Error: syntax-error: malformed if: (if)"},
+		{"(list 1\n (. 2))", "2:3: syntax error: bad dot syntax"},
+		{"(list 1\n  #q)", "2:3: invalid token: #q"},
+		{"(define-macro (m x) (list 'car x))\n\n(m 1)", "3:1: Compile Error: pair required, but got 1"},
+		{"(define (f)\n (raise 'oops))\n(f)", "2:2: unhandled exception: oops"},
+	}
+	for _, test := range tests {
+		ioutil.WriteFile(file.Name(), []byte(test.source), os.ModeAppend)
+		source := fmt.Sprintf("(load \"%s\")", file.Name())
+		expect := fmt.Sprintf("*** ERROR: %s:%s", file.Name(), test.result)
+		if actual := NewInterpreter(source).EvalResults(false)[0]; actual != expect {
+			t.Errorf("%q => %s; want %s", test.source, actual, expect)
+		}
+	}
+}
+
+func TestEval(t *testing.T) {
+	result, err := NewInterpreter("(define x 2) (* x 3)").Eval(false)
+	if err != nil || result.String() != "6" {
+		t.Errorf("Eval() => %s, %v; want 6, <nil>", result, err)
+	}
+
+	tests := []struct {
+		source    string
+		category  ErrorCategory
+		message   string
+		irritants string
+		location  string
+	}{
+		{"(error \"bad thing:\" 1 'two)", UserError, "bad thing: 1 two", "(1 two)", "1:1"},
+		{"(raise 'oops)", UserError, "unhandled exception: oops", "(oops)", "1:1"},
+		{"(car 1)", RuntimeError, "pair required, but got 1", "(1)", "1:1"},
+		{"undefined", RuntimeError, "unbound variable: undefined", "(undefined)", "1:1"},
+		{"(+ 1 . 2)", CompileError, "proper list required for function application or macro use", "()", "1:1"},
+		{"(quote)", SyntaxError, "malformed quote: (quote)", "(quote (quote))", "1:1"},
+		{"1\n (1 . 2 3)", SyntaxError, "syntax error: bad dot syntax", "()", "2:9"},
+		{"(list #q)", SyntaxError, "invalid token: #q", "()", "1:7"},
+	}
+	for _, test := range tests {
+		_, err := NewInterpreter(test.source).Eval(false)
+		schemeError, ok := err.(*SchemeError)
+		if !ok {
+			t.Errorf("%s => %v; want *SchemeError", test.source, err)
+			continue
+		}
+
+		irritants := NewList(nil, schemeError.Irritants...).String()
+		location := ""
+		if schemeError.Location != nil {
+			location = schemeError.Location.String()
+		}
+		if schemeError.Category != test.category || schemeError.Message != test.message ||
+			irritants != test.irritants || location != test.location {
+			t.Errorf("%s => %s, %q, %s, %q; want %s, %q, %s, %q", test.source,
+				schemeError.Category, schemeError.Message, irritants, location,
+				test.category, test.message, test.irritants, test.location)
+		}
+	}
+
+	result, err = NewInterpreter("1 (car 1)").Eval(false)
+	if result != nil || err == nil || err.Error() != "Compile Error: pair required, but got 1" {
+		t.Errorf("1 (car 1) => %v, %v; want <nil>, Compile Error: pair required, but got 1", result, err)
+	}
+}
+
+func TestRaisingEvaluation(t *testing.T) {
+	interpreter := NewInterpreter("")
+	environment, other := newEvaluation(interpreter.environment), newEvaluation(interpreter.environment)
+	car := interpreter.environment.lookup("car").(*Subroutine)
+
+	r := func() (r interface{}) {
+		defer func() { r = recover() }()
+		return car.Call(NewList(nil, NewNumber(1)), environment)
+	}()
+	if !isRaising(environment) || isRaising(other) {
+		t.Errorf("built-in error is not counted only by the evaluation which raised it")
+	}
+	NewSchemeError(r)
+	if isRaising(environment) {
+		t.Errorf("built-in error reported as SchemeError is still counted")
+	}
+}
+
+func TestEvalDepth(t *testing.T) {
+	defaultDepth := maxEvalDepth
+	maxEvalDepth = 100
+	defer func() { maxEvalDepth = defaultDepth }()
+
+	tests := []interpreterTest{
+		evalTest("(define (f x) (if (= x 0) 'done (f (- x 1)))) (f 1000)", "f", "done"),
+		evalTest("(define (f x) (and #t (or #f (begin (let* ((y x)) (letrec ((z y)) (if (= z 0) 'done (f (- z 1))))))))) (f 1000)", "f", "done"),
+		evalTest("(define (f x) (if (= x 0) 0 (+ 1 (f (- x 1))))) (f 50) (f 1000)", "f", "50", "*** ERROR: stack overflow: recursion is too deep"),
+		evalTest("(define (f x) (if (= x 0) 0 (+ 1 (f (- x 1))))) (guard (e ((error-object? e) (error-object-kind e))) (f 1000)) (f 50)", "f", "stack-overflow", "50"),
+		evalTest("(define (f x) (if (= x 0) 0 (+ 1 (f (- x 1))))) (call/cc (lambda (k) (with-exception-handler (lambda (e) (k (error-object-message e))) (lambda () (f 1000)))))", "f", `"stack overflow: recursion is too deep"`),
+	}
+	runTests(t, tests)
+
+	// concurrent evaluations have their own depth
+	interpreter := NewInterpreter("(define (f x) (if (= x 0) 0 (+ 1 (f (- x 1)))))")
+	interpreter.EvalResults(false)
+	f := interpreter.environment.lookup("f").(*Closure)
+
+	errors := make(chan interface{}, 8)
+	for n := 0; n < 8; n++ {
+		go func() {
+			defer func() { errors <- recover() }()
+			for count := 0; count < 100; count++ {
+				f.Call([]Object{NewNumber(80)})
+			}
+		}()
+	}
+	for n := 0; n < 8; n++ {
+		if err := <-errors; err != nil {
+			t.Errorf("concurrent call of f => %v", err)
+		}
+	}
+}
+
+func TestConcurrentClosureCall(t *testing.T) {
+	interpreter := NewInterpreter("(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))")
+	interpreter.EvalResults(false)
+	fib := interpreter.environment.lookup("fib").(*Closure)
+
+	expects := []int{0, 1, 1, 2, 3, 5, 8, 13, 21, 34, 55}
+	results := make(chan string, len(expects))
+	for n := range expects {
+		go func(n int) {
+			results <- fmt.Sprintf("%d:%s", n, fib.Call([]Object{NewNumber(n)}))
+		}(n)
+	}
+
+	actuals := map[string]bool{}
+	for range expects {
+		actuals[<-results] = true
+	}
+	for n, expect := range expects {
+		if result := fmt.Sprintf("%d:%d", n, expect); !actuals[result] {
+			t.Errorf("(fib %d) => %v; want %d", n, actuals, expect)
+		}
+	}
+}
+
+// Interpreter a is blocked in its dynamic extent while b is evaluated.
+func testConcurrentInterpreters(t *testing.T, a string, b string, expectA string, expectB string) {
+	entered, release := make(chan bool), make(chan bool)
+	interpreterA := NewInterpreter(a)
+	interpreterA.environment.define("wait", NewSubroutine(func(s *Subroutine, arguments Object, environment *Environment) Object {
+		entered <- true
+		<-release
+		return NewSymbol("done")
+	}))
+	results := make(chan []string)
+	go func() {
+		results <- interpreterA.EvalResults(false)
+	}()
+	<-entered
+
+	if actual := NewInterpreter(b).EvalResults(false); actual[len(actual)-1] != expectB {
+		t.Errorf("%s => %s; want %s", b, actual[len(actual)-1], expectB)
+	}
+	close(release)
+	if actual := <-results; actual[len(actual)-1] != expectA {
+		t.Errorf("%s => %s; want %s", a, actual[len(actual)-1], expectA)
+	}
+}
+
+func TestConcurrentInterpreters(t *testing.T) {
+	testConcurrentInterpreters(
+		t,
+		"(with-exception-handler (lambda (e) 'from-a) (lambda () (wait)))",
+		"(raise-continuable 'b-error)",
+		"done",
+		"*** ERROR: unhandled exception: b-error",
+	)
+	testConcurrentInterpreters(
+		t,
+		"(list (wait))",
+		"(length (current-backtrace))",
+		"(done)",
+		"1",
+	)
+	testConcurrentInterpreters(
+		t,
+		"(with-input-from-file \"interpreter_test.go\" (lambda () (wait)))",
+		"(current-input-port)",
+		"done",
+		"#<input-port stdin>",
+	)
+	testConcurrentInterpreters(
+		t,
+		"(with-output-to-string (lambda () (wait)))",
+		"(current-output-port)",
+		"\"\"",
+		"#<output-port stdout>",
+	)
+}
+
+func benchmarkInterpreter(b *testing.B, definition string, source string) {
+	interpreter := NewInterpreter(definition)
+	interpreter.EvalResults(false)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		interpreter.ReloadSourceCode(source)
+		interpreter.EvalResults(false)
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	benchmarkInterpreter(b, "(define (fib n) (if (< n 2) n (+ (fib (- n 1)) (fib (- n 2)))))", "(fib 20)")
+}
+
+func BenchmarkTak(b *testing.B) {
+	benchmarkInterpreter(b, "(define (tak x y z) (if (not (< y x)) z (tak (tak (- x 1) y z) (tak (- y 1) z x) (tak (- z 1) x y))))", "(tak 18 12 6)")
+}
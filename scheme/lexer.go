@@ -5,14 +5,39 @@ package scheme
 
 import (
 	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/scanner"
+	"unicode"
 )
 
 type Lexer struct {
 	scanner.Scanner
-	results []Object
+	results  Object    // list of data read by parser
+	dots     []bool    // whether '.' appeared in each open list
+	closed   bool      // whether the last token closed a list
+	file     string    // name of source file, or empty
+	location *Location // location of the last token
+	peeked   *peekedToken
+
+	// locations of symbols which are cars of pairs, because a symbol has no location
+	locations map[*Pair]*Location
+	// lists read from abbreviations 'datum, which are quoted as they are read
+	quotations map[*Pair]bool
+
+	// A lexer for read procedure stops after a datum.
+	datumOnly    bool
+	datumStarted bool
+	datumDone    bool
+	depth        int // nesting level of lists in the datum
+}
+
+// A token which is scanned by PeekToken and not consumed yet.
+type peekedToken struct {
+	text     string
+	location *Location
 }
 
 const (
@@ -28,36 +53,160 @@ var numberChars = "0-9+-."
 var identifierExp = fmt.Sprintf("[%s][%s%s]*", identifierChars, identifierChars, numberChars)
 
 func NewLexer(source string) *Lexer {
-	lexer := new(Lexer)
-	lexer.Init(strings.NewReader(source))
-	lexer.Mode &^= scanner.ScanChars
+	return newReaderLexer(strings.NewReader(source))
+}
+
+func newReaderLexer(reader io.Reader) *Lexer {
+	lexer := &Lexer{locations: map[*Pair]*Location{}, quotations: map[*Pair]bool{}}
+	lexer.Init(reader)
+	lexer.Mode &^= scanner.ScanChars | scanner.ScanRawStrings
 	return lexer
 }
 
 func (l *Lexer) Lex(lval *yySymType) int {
+	if l.datumOnly && !l.datumStarted {
+		l.datumStarted = true
+		return DATUM_START
+	} else if l.datumOnly && l.datumDone {
+		return EOF
+	}
+
 	token := int(l.TokenType())
 	lval.token = l.NextToken()
+	lval.location = l.location
+
+	// a list is popped after its ')' is accepted by parser
+	if l.closed && len(l.dots) > 0 {
+		l.dots = l.dots[:len(l.dots)-1]
+	}
+	l.closed = token == ')'
+
+	switch token {
+	case '(', VECTOR_START:
+		l.dots = append(l.dots, false)
+	case '.':
+		if len(l.dots) > 0 {
+			l.dots[len(l.dots)-1] = true
+		}
+	}
+
+	if l.datumOnly {
+		l.trackDatum(token)
+	}
 	return token
 }
 
+// Record that token is read in a datum, which is done when all lists are closed.
+// A quote is followed by a datum.
+func (l *Lexer) trackDatum(token int) {
+	switch token {
+	case '(', VECTOR_START:
+		l.depth++
+	case ')':
+		l.depth--
+	case '\'', '`', ',', UNQUOTE_SPLICING:
+		return
+	}
+	l.datumDone = l.depth <= 0
+}
+
 func (l *Lexer) Error(e string) {
-	panic(e)
+	// a parse error in a list which has '.' is caused by the position of '.'
+	if len(l.dots) > 0 && l.dots[len(l.dots)-1] {
+		e = fmt.Sprintf("%s: bad dot syntax", e)
+	}
+
+	readError(l.location, "%s", e)
+}
+
+// Raise a read error at location.
+func readError(location *Location, format string, a ...interface{}) {
+	errorObject := NewErrorObject(errorKindRead, fmt.Sprintf(format, a...), []Object{})
+	errorObject.location = location
+	raiseBuiltin(errorObject)
+}
+
+// Returns the text of a string literal, whose escape sequences are replaced.
+func unescapeString(token string, location *Location) string {
+	runes := []rune(token[1 : len(token)-1])
+	var builder strings.Builder
+	for index := 0; index < len(runes); index++ {
+		if runes[index] != '\\' {
+			builder.WriteRune(runes[index])
+			continue
+		}
+
+		index++
+		switch char := runes[index]; char {
+		case 'a':
+			builder.WriteRune('\a')
+		case 'b':
+			builder.WriteRune('\b')
+		case 't':
+			builder.WriteRune('\t')
+		case 'n':
+			builder.WriteRune('\n')
+		case 'r':
+			builder.WriteRune('\r')
+		case '"', '\\', '|':
+			builder.WriteRune(char)
+		case 'x', 'X':
+			end := index + 1
+			for end < len(runes) && runes[end] != ';' {
+				end++
+			}
+			value, err := strconv.ParseUint(string(runes[index+1:end]), 16, 32)
+			if end == len(runes) || err != nil || value > unicode.MaxRune {
+				readError(location, "invalid hex escape in string: %s", token)
+			}
+			builder.WriteRune(rune(value))
+			index = end
+		default:
+			// a line continuation skips whitespace around a newline
+			rest := index
+			for rest < len(runes) && (runes[rest] == ' ' || runes[rest] == '\t') {
+				rest++
+			}
+			if rest == len(runes) || runes[rest] != '\n' {
+				readError(location, "invalid escape sequence in string: %s", token)
+			}
+			for rest++; rest < len(runes) && (runes[rest] == ' ' || runes[rest] == '\t'); rest++ {
+			}
+			index = rest - 1
+		}
+	}
+	return builder.String()
+}
+
+// Returns the character of a character literal.
+func unescapeChar(token string, location *Location) rune {
+	value, ok := parseChar(token)
+	if !ok {
+		readError(location, "invalid character name: %s", token)
+	}
+	return value
 }
 
 // Non-destructive scanner.Scan().
 // This method returns next token type or unicode character.
-func (l Lexer) TokenType() rune {
+func (l *Lexer) TokenType() rune {
 	token := l.PeekToken()
 	if l.matchRegexp(token, "^[ ]*$") {
 		return EOF
-	} else if l.matchRegexp(token, fmt.Sprintf("^(%s|\\+|-)$", identifierExp)) {
-		return IDENTIFIER
-	} else if l.matchRegexp(token, "^-?[0-9]+$") {
+	} else if parseNumber(token, 10) != nil {
 		return NUMBER
+	} else if l.matchRegexp(token, fmt.Sprintf("^(%s|\\+|-|\\.\\.\\.)$", identifierExp)) {
+		return IDENTIFIER
 	} else if l.matchRegexp(token, "^#(f|t)$") {
 		return BOOLEAN
-	} else if l.matchRegexp(token, "\"[^\"]*\"") {
+	} else if token == "#(" {
+		return VECTOR_START
+	} else if strings.HasPrefix(token, "#\\") {
+		return CHAR
+	} else if l.matchRegexp(token, `(?s)^"(\\.|[^"\\])*"$`) {
 		return STRING
+	} else if token == ",@" {
+		return UNQUOTE_SPLICING
 	} else {
 		runes := []rune(token)
 		return runes[0]
@@ -65,23 +214,44 @@ func (l Lexer) TokenType() rune {
 }
 
 // Non-destructive Lexer.NextToken().
-func (l Lexer) PeekToken() string {
-	return l.nextToken()
+// The token is scanned once and kept until it is consumed, because the scanner
+// cannot be copied to look ahead without reading its source.
+func (l *Lexer) PeekToken() string {
+	if l.peeked == nil {
+		location := l.location
+		text := l.nextToken()
+		l.peeked = &peekedToken{text: text, location: l.location}
+		l.location = location
+	}
+	return l.peeked.text
 }
 
 // This function returns next token and moves current token reading
 // position to next token position.
 func (l *Lexer) NextToken() string {
-	defer l.ensureAvailability()
-	return l.nextToken()
+	text := l.PeekToken()
+	l.location = l.peeked.location
+	l.peeked = nil
+	return text
 }
 
-func (l Lexer) IndentLevel() int {
+// Returns the number of lists which are not closed in source.
+// If source has an invalid token, it is complete to be reported by evaluation.
+func (l Lexer) IndentLevel() (level int) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*pendingError); !ok {
+				panic(r)
+			}
+			level = 0
+		}
+	}()
+
 	tokens := l.AllTokens()
 	openCount, closedCount := 0, 0
 
 	for _, token := range tokens {
-		if token == "(" {
+		if token == "(" || token == "#(" {
 			openCount++
 		} else if token == ")" {
 			closedCount++
@@ -104,23 +274,26 @@ func (l Lexer) AllTokens() []string {
 }
 
 func (l *Lexer) nextToken() string {
-	// text/scanner scans text which starts with "'" in one token.
-	if l.Peek() == '\'' {
+	l.skipWhitespace()
+	switch l.Peek() {
+	case '\'':
+		// text/scanner scans text which starts with "'" in one token.
+		l.location = l.locationOf(l.Pos())
 		l.Next()
 		return "'"
+	case '"':
+		// text/scanner does not accept escape sequences of scheme.
+		l.location = l.locationOf(l.Pos())
+		return l.scanString()
+	case '#':
+		l.location = l.locationOf(l.Pos())
+		l.Next()
+		return l.scanSharp()
 	}
 
 	l.Scan()
-	if l.TokenText() == "#" {
-		// text/scanner scans '#t' as '#' and 't'.
-		l.Scan()
-		switch l.TokenText() {
-		case "t", "f":
-			return fmt.Sprintf("#%s", l.TokenText())
-		default:
-			runtimeError("Tokens which start from '#' are not implemented except #f, #t.")
-		}
-	} else if l.matchRegexp(l.TokenText(), fmt.Sprintf("^%s$", identifierExp)) {
+	l.location = l.locationOf(l.Position)
+	if l.matchRegexp(l.TokenText(), fmt.Sprintf("^%s$", identifierExp)) {
 		// text/scanner scans some signs as splitted token from alphabet token.
 		text := l.TokenText()
 		for l.isIdentifierChar(l.Peek()) {
@@ -128,29 +301,117 @@ func (l *Lexer) nextToken() string {
 			text = fmt.Sprintf("%s%s", text, l.TokenText())
 		}
 		return text
-	} else if l.TokenText() == "-" && l.matchRegexp(fmt.Sprintf("%c", l.Peek()), "[0-9]") {
+	} else if l.TokenText() == "," && l.Peek() == '@' {
+		l.Next()
+		return ",@"
+	} else if l.TokenText() == "." && l.Peek() == '.' {
+		// text/scanner scans "..." as three periods.
 		text := l.TokenText()
-		l.Scan()
-		text = text + l.TokenText()
+		for l.Peek() == '.' {
+			l.Next()
+			text += "."
+		}
 		return text
+	} else if l.isNumberStart(l.TokenText()) {
+		// text/scanner does not scan numbers such as 1/2, +inf.0 and 1+2i in one token.
+		return l.TokenText() + l.scanRest()
 	}
 	return l.TokenText()
 }
 
+// Scan a token which starts with '#', such as #t, #\\a and #x1F.
+func (l *Lexer) scanSharp() string {
+	if l.Peek() == '(' {
+		l.Next()
+		return "#("
+	} else if l.Peek() == '\\' {
+		// a character literal, whose first character may be a delimiter like #\\(
+		l.Next()
+		text := "#\\"
+		if char := l.Next(); char != scanner.EOF {
+			text += string(char)
+		}
+		return text + l.scanRest()
+	}
+
+	l.Scan()
+	switch text := l.TokenText(); {
+	case text == "t" || text == "f":
+		return fmt.Sprintf("#%s", text)
+	case text != "" && strings.ContainsRune("bodxeiBODXEI", rune(text[0])):
+		// prefixes of number such as #x1F and #e1.5
+		return "#" + text + l.scanRest()
+	default:
+		readError(l.location, "invalid token: #%s", text)
+	}
+	return ""
+}
+
+// Scan a string literal including its double quotes and escape sequences.
+// An unterminated string is returned without closing quote.
+func (l *Lexer) scanString() string {
+	text := string(l.Next())
+	for {
+		char := l.Next()
+		if char == scanner.EOF {
+			return text
+		}
+		text += string(char)
+		switch char {
+		case '\\':
+			if char = l.Next(); char != scanner.EOF {
+				text += string(char)
+			}
+		case '"':
+			return text
+		}
+	}
+}
+
+func (l *Lexer) skipWhitespace() {
+	for char := l.Peek(); char >= 0 && char < 64 && l.Whitespace&(1<<uint(char)) != 0; char = l.Peek() {
+		l.Next()
+	}
+}
+
+func (l *Lexer) locationOf(position scanner.Position) *Location {
+	if !position.IsValid() {
+		return nil
+	}
+	return &Location{File: l.file, Line: position.Line, Column: position.Column}
+}
+
+// Returns true if text is scanned as a beginning of number.
+// A sign followed by delimiter is an identifier.
+func (l *Lexer) isNumberStart(text string) bool {
+	switch {
+	case text == "":
+		return false
+	case text == "+" || text == "-":
+		return !isDelimiter(l.Peek())
+	default:
+		return unicode.IsDigit(rune(text[0])) || (text[0] == '.' && len(text) > 1)
+	}
+}
+
+// Scan characters until a delimiter, and returns them as a string.
+func (l *Lexer) scanRest() string {
+	text := ""
+	for !isDelimiter(l.Peek()) {
+		text += string(l.Next())
+	}
+	return text
+}
+
+func isDelimiter(char rune) bool {
+	return char == scanner.EOF || unicode.IsSpace(char) || strings.ContainsRune("()\";'`,", char)
+}
+
 func (l Lexer) isIdentifierChar(char rune) bool {
 	charString := fmt.Sprintf("%c", char)
 	return l.matchRegexp(charString, fmt.Sprintf("^[%s%s]$", identifierChars, numberChars))
 }
 
 func (l *Lexer) matchRegexp(matchString string, expression string) bool {
-	re, err := regexp.Compile(expression)
-	if err != nil {
-		runtimeError(err.Error())
-	}
-	return re.MatchString(matchString)
-}
-
-func (l *Lexer) ensureAvailability() {
-	// Error message will be printed by interpreter
-	recover()
+	return regexp.MustCompile(expression).MatchString(matchString)
 }
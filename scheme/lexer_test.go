@@ -20,9 +20,20 @@ var tokenTypeTests = []tokenTypeTest{
 	{"(", '('},
 	{")", ')'},
 	{"'", '\''},
+	{"`", '`'},
+	{",", ','},
+	{",@", UNQUOTE_SPLICING},
+	{".", '.'},
 
 	{"100", NUMBER},
 	{"-1", NUMBER},
+	{"1/2", NUMBER},
+	{"-1.5e10", NUMBER},
+	{".5", NUMBER},
+	{"+inf.0", NUMBER},
+	{"1+2i", NUMBER},
+	{"#x-1F", NUMBER},
+	{"#e1.5", NUMBER},
 
 	{"#f", BOOLEAN},
 	{"#t", BOOLEAN},
@@ -31,8 +42,13 @@ var tokenTypeTests = []tokenTypeTest{
 	{"-", IDENTIFIER},
 	{"f2000", IDENTIFIER},
 	{"a0?!*/<=>:$%^&_~", IDENTIFIER},
+	{"...", IDENTIFIER},
 
 	{"\"a b\"", STRING},
+	{`"a\"b"`, STRING},
+	{`#\a`, CHAR},
+	{`#\space`, CHAR},
+	{"#(", VECTOR_START},
 }
 
 var tokenizeTests = []tokenizeTest{
@@ -45,6 +61,9 @@ var tokenizeTests = []tokenizeTest{
 	{"(+ 1 (+ 1))", makeTokens("(,+,1,(,+,1,),)")},
 	{"(+ (- 1)2)", makeTokens("(,+,(,-,1,),2,)")},
 	{"(* (/ 1)2)", makeTokens("(,*,(,/,1,),2,)")},
+	{"(+ 1/2 -1.5e-3 +inf.0)", makeTokens("(,+,1/2,-1.5e-3,+inf.0,)")},
+	{"(- 1+2i -i)", makeTokens("(,-,1+2i,-i,)")},
+	{"(#x1F #e1.5)", makeTokens("(,#x1F,#e1.5,)")},
 	{"(number? 1)", makeTokens("(,number?,1,)")},
 	{"(string-append \"\")", makeTokens("(,string-append,\"\",)")},
 
@@ -60,8 +79,14 @@ var tokenizeTests = []tokenizeTest{
 	{"'(1 2 3)", makeTokens("',(,1,2,3,)")},
 	{"'(1(2 3))", makeTokens("',(,1,(,2,3,),)")},
 	{"\"a b\"", makeTokens("\"a b\"")},
+	{`("a\"b" "c")`, []string{"(", `"a\"b"`, `"c"`, ")"}},
+	{`(#\( #\space #\))`, []string{"(", `#\(`, `#\space`, `#\)`, ")"}},
+	{"#(1 #(2) '#())", makeTokens("#(,1,#(,2,),',#(,),)")},
 
 	{"(set! x 1)", makeTokens("(,set!,x,1,)")},
+	{"(a ... b)", makeTokens("(,a,...,b,)")},
+	{"(a . b)", makeTokens("(,a,.,b,)")},
+	{"`(a ,b ,@c)", []string{"`", "(", "a", ",", "b", ",@", "c", ")"}},
 }
 
 func TestTokenType(t *testing.T) {
@@ -86,6 +111,31 @@ func TestAllTokens(t *testing.T) {
 	}
 }
 
+func TestIndentLevel(t *testing.T) {
+	tests := []struct {
+		source string
+		result int
+	}{
+		{"(a (b", 2},
+		{"(a #(b)) c)", -1},
+		{"(a #q", 0},
+	}
+	for _, test := range tests {
+		if actual := NewLexer(test.source).IndentLevel(); actual != test.result {
+			t.Errorf("%s => %d; want %d", test.source, actual, test.result)
+		}
+	}
+}
+
+func TestNextTokenError(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*pendingError); !ok {
+			t.Errorf("invalid token is not raised by NextToken")
+		}
+	}()
+	NewLexer("#q").NextToken()
+}
+
 func tokenTypeString(tokenType rune) string {
 	switch tokenType {
 	case EOF:
@@ -1,3 +1,7 @@
+// Macro is a type for a transformer defined by define-macro.
+// Its transformer is a closure which receives unevaluated argument forms
+// as data and returns an expansion, which is evaluated in caller's scope.
+
 package scheme
 
 import (
@@ -6,14 +10,11 @@ import (
 
 type Macro struct {
 	ObjectBase
+	transformer *Closure
 }
 
-func NewMacro() *Macro {
-	return &Macro{}
-}
-
-func (m *Macro) Eval() Object {
-	return m
+func NewMacro(transformer *Closure) *Macro {
+	return &Macro{transformer: transformer}
 }
 
 func (m *Macro) String() string {
@@ -22,3 +23,23 @@ func (m *Macro) String() string {
 	}
 	return fmt.Sprintf("#<macro %s>", m.Bounder())
 }
+
+// Expand given arguments, which must be a list of data, by one step.
+func (m *Macro) Expand(arguments Object, scope *Scope) Object {
+	assertListMinimum(arguments, 0)
+	return m.transformer.Call(arguments.(*Pair).Elements())
+}
+
+// Returns the expansion of given form and true if form is a macro use in environment.
+// Otherwise returns form itself and false.
+func expandMacro(form Object, environment *Environment) (Object, bool) {
+	if !form.isPair() || !form.(*Pair).Car.isSymbol() {
+		return form, false
+	}
+
+	object := environment.lookup(form.(*Pair).Car.(*Symbol).identifier)
+	if expander, ok := object.(Expander); ok {
+		return expander.Expand(form.(*Pair).Cdr, NewGlobalScope(environment.global())), true
+	}
+	return form, false
+}
@@ -18,6 +18,28 @@ func areEqual(a Object, b Object) bool {
 	switch a.(type) {
 	case *Pair:
 		return areEqual(a.(*Pair).Car, b.(*Pair).Car) && areEqual(a.(*Pair).Cdr, b.(*Pair).Cdr)
+	case *Vector:
+		if len(a.(*Vector).elements) != len(b.(*Vector).elements) {
+			return false
+		}
+		for index, element := range a.(*Vector).elements {
+			if !areEqual(element, b.(*Vector).elements[index]) {
+				return false
+			}
+		}
+		return true
+	case *String:
+		return a.(*String).text() == b.(*String).text()
+	case *Record:
+		if a.(*Record).recordType != b.(*Record).recordType {
+			return false
+		}
+		for index, field := range a.(*Record).fields {
+			if !areEqual(field, b.(*Record).fields[index]) {
+				return false
+			}
+		}
+		return true
 	default:
 		return false
 	}
@@ -30,9 +52,11 @@ func areIdentical(a Object, b Object) bool {
 
 	switch a.(type) {
 	case *Number:
-		return a.(*Number).value == b.(*Number).value
+		return areEqvNumbers(a.(*Number), b.(*Number))
 	case *Boolean:
 		return a.(*Boolean).value == b.(*Boolean).value
+	case *Char:
+		return a.(*Char).value == b.(*Char).value
 	default:
 		return a == b
 	}
@@ -51,11 +75,16 @@ func areSameList(a Object, b Object) bool {
 	}
 }
 
+func arityError(format string, a ...interface{}) Object {
+	return raiseErrorWithHeading("Compile Error: ", errorKindArity, format, a...)
+}
+
 func assertListMinimum(arguments Object, minimum int) {
 	if !arguments.isList() {
 		compileError("proper list required for function application or macro use")
 	} else if arguments.(*Pair).ListLength() < minimum {
-		compileError("procedure requires at least %d argument", minimum)
+		arityError("wrong number of arguments: requires at least %d, but got %d",
+			minimum, arguments.(*Pair).ListLength())
 	}
 }
 
@@ -63,25 +92,87 @@ func assertListEqual(arguments Object, length int) {
 	if !arguments.isList() {
 		compileError("proper list required for function application or macro use")
 	} else if arguments.(*Pair).ListLength() != length {
-		compileError("wrong number of arguments: requires %d, but got %d",
+		arityError("wrong number of arguments: requires %d, but got %d",
 			length, arguments.(*Pair).ListLength())
 	}
 }
 
+// Assert that object is an exact integer from minimum to maximum, and returns it.
+func assertIndex(object Object, minimum int, maximum int) int {
+	assertObjectType(object, "number")
+	index, ok := object.(*Number).value.(int)
+	if !ok || index < minimum || index > maximum {
+		raiseError(errorKindOutOfRange, "index out of range: %s", object)
+	}
+	return index
+}
+
+// Returns a range of indices given as optional arguments start and end,
+// which are 0 and length by default.
+func rangeArguments(objects []Object, length int) (int, int) {
+	start, end := 0, length
+	if len(objects) > 1 {
+		end = assertIndex(objects[1], 0, length)
+	}
+	if len(objects) > 0 {
+		start = assertIndex(objects[0], 0, end)
+	}
+	return start, end
+}
+
+func assertListRange(arguments Object, minimum int, maximum int) {
+	assertListMinimum(arguments, minimum)
+	if arguments.(*Pair).ListLength() > maximum {
+		arityError("wrong number of arguments: requires %d to %d, but got %d",
+			minimum, maximum, arguments.(*Pair).ListLength())
+	}
+}
+
 func assertObjectsType(objects []Object, typeName string) {
 	for _, object := range objects {
 		assertObjectType(object, typeName)
 	}
 }
 
+func assertRealNumbers(objects []Object) {
+	for _, object := range objects {
+		assertObjectType(object, "number")
+		if !object.(*Number).isReal() {
+			typeError("real number required, but got %s", object)
+		}
+	}
+}
+
+func assertInteger(object Object) {
+	assertObjectType(object, "number")
+	if !object.(*Number).isInteger() {
+		typeError("integer required, but got %s", object)
+	}
+}
+
+func assertProcedure(object Object) {
+	if !object.isProcedure() {
+		typeError("procedure required, but got %s", object)
+	}
+}
+
 func assertObjectType(object Object, assertType string) {
 	if assertType != typeName(object) {
-		compileError("%s required, but got %s", assertType, object)
+		typeError("%s required, but got %s", assertType, object)
 	}
 }
 
 func compileError(format string, a ...interface{}) Object {
-	return runtimeError("Compile Error: "+format, a...)
+	return raiseErrorWithHeading("Compile Error: ", errorKindCompile, format, a...)
+}
+
+// Name builtin procedures and syntaxes by their keys.
+func init() {
+	for _, binding := range []Binding{builtinProcedures, builtinSyntaxes} {
+		for key, value := range binding {
+			value.setBounder(NewVariable(key, nil))
+		}
+	}
 }
 
 func defaultBinding() Binding {
@@ -95,22 +186,88 @@ func defaultBinding() Binding {
 	return binding
 }
 
-func evaledObjects(objects []Object) []Object {
-	evaledObjects := []Object{}
-
-	for _, object := range objects {
-		evaledObjects = append(evaledObjects, object.Eval())
-	}
-	return evaledObjects
+func fileError(format string, a ...interface{}) Object {
+	return raiseError(errorKindFile, format, a...)
 }
 
 func runtimeError(format string, a ...interface{}) Object {
-	panic(fmt.Sprintf(format, a...))
-	return undef
+	return raiseError(errorKindRuntime, format, a...)
 }
 
 func syntaxError(format string, a ...interface{}) Object {
-	return compileError("syntax-error: "+format, a...)
+	return raiseErrorWithHeading("Compile Error: syntax-error: ", errorKindSyntax, format, a...)
+}
+
+// Convert an expression of AST into data, which is passed to macro transformers.
+// Literals written with single quote are converted to (quote ...) forms.
+func toDatum(object Object) Object {
+	switch object.(type) {
+	case *Application:
+		application := object.(*Application)
+		if isQuotation(application) {
+			return &Pair{Car: toDatum(application.procedure), Cdr: application.arguments}
+		}
+		return &Pair{Car: toDatum(application.procedure), Cdr: toDatumList(application.arguments)}
+	case *Variable:
+		if object.(*Variable).alias != nil {
+			return object.(*Variable).alias
+		}
+		return NewSymbol(object.(*Variable).identifier)
+	case *Symbol:
+		return NewList(nil, NewSymbol("quote"), object)
+	case *Pair:
+		if object.isNull() {
+			return Null
+		}
+		return NewList(nil, NewSymbol("quote"), object)
+	default:
+		return object
+	}
+}
+
+// Convert a list of expressions, such as application's arguments, into a list of data.
+func toDatumList(list Object) Object {
+	if list.isNull() {
+		return Null
+	} else if list.isPair() {
+		return &Pair{Car: toDatum(list.(*Pair).Car), Cdr: toDatumList(list.(*Pair).Cdr)}
+	}
+	return toDatum(list)
+}
+
+// Returns a representation of object for display, where strings and
+// characters are written as their contents.
+func displayString(object Object) string {
+	switch object.(type) {
+	case *String:
+		return object.(*String).text()
+	case *Char:
+		return string(object.(*Char).value)
+	case *Pair:
+		if object.isNull() {
+			return "()"
+		}
+		tokens := []string{}
+		for ; object.isPair(); object = object.(*Pair).Cdr {
+			tokens = append(tokens, displayString(object.(*Pair).Car))
+		}
+		if !object.isNull() {
+			tokens = append(tokens, ".", displayString(object))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(tokens, " "))
+	case *Vector:
+		tokens := []string{}
+		for _, element := range object.(*Vector).elements {
+			tokens = append(tokens, displayString(element))
+		}
+		return fmt.Sprintf("#(%s)", strings.Join(tokens, " "))
+	default:
+		return object.String()
+	}
+}
+
+func typeError(format string, a ...interface{}) Object {
+	return raiseErrorWithHeading("Compile Error: ", errorKindWrongType, format, a...)
 }
 
 func typeName(object Object) string {
@@ -121,6 +278,22 @@ func typeName(object Object) string {
 		} else {
 			return "pair"
 		}
+	case *Number:
+		return "number"
+	case *String:
+		return "string"
+	case *Boolean:
+		return "boolean"
+	case *Symbol:
+		return "symbol"
+	case *ErrorObject:
+		return "error-object"
+	case *HashTable:
+		return "hash-table"
+	case *RecordType:
+		return "record-type"
+	case *EOFObject:
+		return "eof-object"
 	default:
 		rawTypeName := fmt.Sprintf("%T", object)
 		typeName := strings.Replace(rawTypeName, "*scheme.", "", 1)
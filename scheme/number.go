@@ -1,28 +1,106 @@
 // Number is a scheme number object, which is expressed by number literal.
+//
+// Numbers make a tower of exact integers, exact rationals, inexact reals and
+// inexact complex numbers. An exact integer is a Go int while it fits in,
+// otherwise it is a *big.Int. A value is always normalized to the lowest
+// level which can represent it, e.g. 4/2 is an integer and 1.0+0.0i is a real.
+// Complex numbers are always inexact.
 
 package scheme
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"regexp"
 	"strconv"
+	"strings"
+)
+
+const (
+	integerLevel  = iota // int or *big.Int
+	rationalLevel        // *big.Rat
+	realLevel            // float64
+	complexLevel         // complex128
 )
 
 type Number struct {
 	ObjectBase
-	value int
+	value interface{} // int, *big.Int, *big.Rat, float64 or complex128
+}
+
+// Operation on two numbers of the same level. Integer returns false on overflow,
+// and an operation whose integer is nil is computed as rationals.
+type numberOperation struct {
+	integer  func(x, y int) (int, bool)
+	bigInt   func(x, y *big.Int) *big.Int
+	rational func(x, y *big.Rat) *big.Rat
+	real     func(x, y float64) float64
+	complex  func(x, y complex128) complex128
 }
 
+var decimalExp = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)(e[+-]?[0-9]+)?$`)
+
+var (
+	addition = numberOperation{
+		integer: func(x, y int) (int, bool) {
+			z := x + y
+			return z, (z > x) == (y > 0)
+		},
+		bigInt:   func(x, y *big.Int) *big.Int { return new(big.Int).Add(x, y) },
+		rational: func(x, y *big.Rat) *big.Rat { return new(big.Rat).Add(x, y) },
+		real:     func(x, y float64) float64 { return x + y },
+		complex:  func(x, y complex128) complex128 { return x + y },
+	}
+	subtraction = numberOperation{
+		integer: func(x, y int) (int, bool) {
+			z := x - y
+			return z, (z < x) == (y > 0)
+		},
+		bigInt:   func(x, y *big.Int) *big.Int { return new(big.Int).Sub(x, y) },
+		rational: func(x, y *big.Rat) *big.Rat { return new(big.Rat).Sub(x, y) },
+		real:     func(x, y float64) float64 { return x - y },
+		complex:  func(x, y complex128) complex128 { return x - y },
+	}
+	multiplication = numberOperation{
+		integer: func(x, y int) (int, bool) {
+			if x == 0 || y == 0 {
+				return 0, true
+			}
+			z := x * y
+			return z, z/y == x && !(x == -1 && y == math.MinInt) && !(y == -1 && x == math.MinInt)
+		},
+		bigInt:   func(x, y *big.Int) *big.Int { return new(big.Int).Mul(x, y) },
+		rational: func(x, y *big.Rat) *big.Rat { return new(big.Rat).Mul(x, y) },
+		real:     func(x, y float64) float64 { return x * y },
+		complex:  func(x, y complex128) complex128 { return x * y },
+	}
+	division = numberOperation{
+		rational: func(x, y *big.Rat) *big.Rat {
+			if y.Sign() == 0 {
+				raiseError(errorKindDivisionByZero, "attempt to divide by zero")
+			}
+			return new(big.Rat).Quo(x, y)
+		},
+		real:    func(x, y float64) float64 { return x / y },
+		complex: func(x, y complex128) complex128 { return x / y },
+	}
+)
+
 func NewNumber(argument interface{}, options ...Object) *Number {
-	var value int
-	var err error
+	var value interface{}
 
 	switch argument.(type) {
-	case int:
-		value = argument.(int)
+	case int, *big.Int, *big.Rat, float64, complex128:
+		value = normalizeNumber(argument)
 	case string:
-		value, err = strconv.Atoi(argument.(string))
-		if err != nil {
-			runtimeError("String conversion %s to integer failed.", argument.(string))
+		number := parseNumber(argument.(string), 10)
+		if number == nil {
+			runtimeError("String conversion %s to number failed.", argument.(string))
 		}
+		value = number.value
 	default:
 		runtimeError("Unexpected argument type for NewNumber()")
 	}
@@ -34,14 +112,609 @@ func NewNumber(argument interface{}, options ...Object) *Number {
 	}
 }
 
-func (n *Number) Eval() Object {
-	return n
+// Returns the value in the lowest level which can represent it.
+func normalizeNumber(value interface{}) interface{} {
+	switch value.(type) {
+	case *big.Int:
+		if value.(*big.Int).IsInt64() && int64(int(value.(*big.Int).Int64())) == value.(*big.Int).Int64() {
+			return int(value.(*big.Int).Int64())
+		}
+	case *big.Rat:
+		if value.(*big.Rat).IsInt() {
+			return normalizeNumber(new(big.Int).Set(value.(*big.Rat).Num()))
+		}
+	case complex128:
+		if imag(value.(complex128)) == 0 {
+			return real(value.(complex128))
+		}
+	}
+	return value
+}
+
+// Parse text as a number literal in radix, which may have prefixes such as #x and #e.
+// Returns nil if text is not a number.
+func parseNumber(text string, radix int) *Number {
+	exactness := byte(0)
+	for len(text) >= 2 && text[0] == '#' {
+		switch prefix := text[1] | 0x20; prefix {
+		case 'b':
+			radix = 2
+		case 'o':
+			radix = 8
+		case 'd':
+			radix = 10
+		case 'x':
+			radix = 16
+		case 'e', 'i':
+			if exactness != 0 {
+				return nil
+			}
+			exactness = prefix
+		default:
+			return nil
+		}
+		text = text[2:]
+	}
+
+	value := parseComplex(strings.ToLower(text), radix, exactness == 'e')
+	if value == nil {
+		return nil
+	}
+	number := &Number{value: normalizeNumber(value)}
+	switch exactness {
+	case 'e':
+		if number.level() == complexLevel {
+			return nil
+		}
+		return number.exact()
+	case 'i':
+		return number.inexact()
+	}
+	return number
+}
+
+// Parse a complex number in rectangular or polar notation, or a real number.
+func parseComplex(text string, radix int, exact bool) interface{} {
+	if index := strings.IndexByte(text, '@'); index >= 0 {
+		magnitude := parseReal(text[:index], radix, exact)
+		angle := parseReal(text[index+1:], radix, exact)
+		if magnitude == nil || angle == nil {
+			return nil
+		}
+		return complex128FromPolar(toFloat(magnitude), toFloat(angle))
+	}
+
+	if !strings.HasSuffix(text, "i") {
+		return parseReal(text, radix, exact)
+	}
+
+	// the imaginary part starts from the last sign which is not in an exponent
+	body := text[:len(text)-1]
+	index := strings.LastIndexAny(body, "+-")
+	for index > 0 && radix == 10 && body[index-1] == 'e' {
+		index = strings.LastIndexAny(body[:index-1], "+-")
+	}
+	if index < 0 {
+		return nil
+	}
+
+	var realPart interface{} = 0
+	if index > 0 {
+		if realPart = parseReal(body[:index], radix, exact); realPart == nil {
+			return nil
+		}
+	}
+	imagText := body[index:]
+	if imagText == "+" || imagText == "-" {
+		imagText += "1"
+	}
+	imagPart := parseReal(imagText, radix, exact)
+	if imagPart == nil {
+		return nil
+	}
+	return complex(toFloat(realPart), toFloat(imagPart))
+}
+
+// Parse an integer, a rational or a decimal, which may be signed.
+// If exact is true, a decimal is parsed as an exact rational.
+func parseReal(text string, radix int, exact bool) interface{} {
+	switch text {
+	case "+inf.0":
+		return math.Inf(1)
+	case "-inf.0":
+		return math.Inf(-1)
+	case "+nan.0", "-nan.0":
+		return math.NaN()
+	}
+
+	if index := strings.IndexByte(text, '/'); index >= 0 {
+		numerator := parseInteger(text[:index], radix)
+		denominator := parseInteger(text[index+1:], radix)
+		if numerator == nil || denominator == nil || denominator.Sign() <= 0 || text[index+1] == '+' {
+			return nil
+		}
+		return new(big.Rat).SetFrac(numerator, denominator)
+	}
+
+	if integer := parseInteger(text, radix); integer != nil {
+		return integer
+	}
+
+	if radix != 10 || !decimalExp.MatchString(text) {
+		return nil
+	}
+	if exact {
+		rational, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return nil
+		}
+		return rational
+	}
+	float, err := strconv.ParseFloat(text, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return nil
+	}
+	return float
+}
+
+// Parse a signed integer which consists of digits in radix.
+func parseInteger(text string, radix int) *big.Int {
+	integer, ok := new(big.Int).SetString(text, radix)
+	if !ok {
+		return nil
+	}
+	return integer
+}
+
+func complex128FromPolar(magnitude, angle float64) complex128 {
+	return complex(magnitude*math.Cos(angle), magnitude*math.Sin(angle))
 }
 
 func (n *Number) String() string {
-	return strconv.Itoa(n.value)
+	switch n.value.(type) {
+	case int:
+		return strconv.Itoa(n.value.(int))
+	case *big.Int:
+		return n.value.(*big.Int).String()
+	case *big.Rat:
+		return n.value.(*big.Rat).String()
+	case float64:
+		return formatFloat(n.value.(float64))
+	default:
+		value := n.value.(complex128)
+		imagText := formatFloat(imag(value))
+		if imagText[0] != '+' && imagText[0] != '-' {
+			imagText = "+" + imagText
+		}
+		return fmt.Sprintf("%s%si", formatFloat(real(value)), imagText)
+	}
+}
+
+// Format a flonum so that it is read as an inexact number again, e.g. 1.0 or 1.0e21.
+func formatFloat(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "+nan.0"
+	case math.IsInf(value, 1):
+		return "+inf.0"
+	case math.IsInf(value, -1):
+		return "-inf.0"
+	}
+
+	if magnitude := math.Abs(value); magnitude == 0 || (magnitude >= 1e-7 && magnitude < 1e21) {
+		text := strconv.FormatFloat(value, 'f', -1, 64)
+		if !strings.Contains(text, ".") {
+			text += ".0"
+		}
+		return text
+	}
+
+	tokens := strings.Split(strconv.FormatFloat(value, 'e', -1, 64), "e")
+	mantissa, exponent := tokens[0], tokens[1]
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	return fmt.Sprintf("%se%s", mantissa, strings.TrimPrefix(exponent, "+"))
 }
 
 func (n *Number) isNumber() bool {
 	return true
 }
+
+func (n *Number) level() int {
+	switch n.value.(type) {
+	case int, *big.Int:
+		return integerLevel
+	case *big.Rat:
+		return rationalLevel
+	case float64:
+		return realLevel
+	default:
+		return complexLevel
+	}
+}
+
+func (n *Number) isExact() bool {
+	return n.level() <= rationalLevel
+}
+
+func (n *Number) isReal() bool {
+	return n.level() <= realLevel
+}
+
+// Returns true if this is an exact number or a finite real number.
+func (n *Number) isRational() bool {
+	if value, ok := n.value.(float64); ok {
+		return !math.IsInf(value, 0) && !math.IsNaN(value)
+	}
+	return n.isExact()
+}
+
+func (n *Number) isInteger() bool {
+	if value, ok := n.value.(float64); ok {
+		return !math.IsInf(value, 0) && value == math.Trunc(value)
+	}
+	return n.level() == integerLevel
+}
+
+// Returns an exact number which is the closest to this number.
+func (n *Number) exact() *Number {
+	switch n.value.(type) {
+	case float64:
+		value := n.value.(float64)
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			typeError("finite number required, but got %s", n)
+		}
+		return NewNumber(new(big.Rat).SetFloat64(value))
+	case complex128:
+		typeError("real number required, but got %s", n)
+	}
+	return n
+}
+
+// Returns an inexact number which is the closest to this number.
+func (n *Number) inexact() *Number {
+	if n.isExact() {
+		return NewNumber(toFloat(n.value))
+	}
+	return n
+}
+
+// Convert a value of numbers to float64.
+func toFloat(value interface{}) float64 {
+	switch value.(type) {
+	case int:
+		return float64(value.(int))
+	case *big.Int:
+		float, _ := new(big.Float).SetInt(value.(*big.Int)).Float64()
+		return float
+	case *big.Rat:
+		float, _ := value.(*big.Rat).Float64()
+		return float
+	case float64:
+		return value.(float64)
+	default:
+		return real(value.(complex128))
+	}
+}
+
+func toBigInt(value interface{}) *big.Int {
+	if integer, ok := value.(int); ok {
+		return big.NewInt(int64(integer))
+	}
+	return value.(*big.Int)
+}
+
+func toRat(value interface{}) *big.Rat {
+	switch value.(type) {
+	case int:
+		return big.NewRat(int64(value.(int)), 1)
+	case *big.Int:
+		return new(big.Rat).SetInt(value.(*big.Int))
+	default:
+		return value.(*big.Rat)
+	}
+}
+
+func toComplex(value interface{}) complex128 {
+	if c, ok := value.(complex128); ok {
+		return c
+	}
+	return complex(toFloat(value), 0)
+}
+
+// Apply operation to numbers after converting them to the higher level of them.
+func (operation *numberOperation) apply(a, b *Number) *Number {
+	level := a.level()
+	if b.level() > level {
+		level = b.level()
+	}
+	if level == integerLevel && operation.integer == nil {
+		level = rationalLevel
+	}
+
+	switch level {
+	case integerLevel:
+		x, xok := a.value.(int)
+		y, yok := b.value.(int)
+		if xok && yok {
+			if z, ok := operation.integer(x, y); ok {
+				return &Number{value: z}
+			}
+		}
+		return NewNumber(operation.bigInt(toBigInt(a.value), toBigInt(b.value)))
+	case rationalLevel:
+		return NewNumber(operation.rational(toRat(a.value), toRat(b.value)))
+	case realLevel:
+		return &Number{value: operation.real(toFloat(a.value), toFloat(b.value))}
+	default:
+		return NewNumber(operation.complex(toComplex(a.value), toComplex(b.value)))
+	}
+}
+
+// Compare two numbers. Returns false if they are not ordered, i.e. NaN is
+// compared or complex numbers are not equal.
+// An inexact number is compared as an exact one, so that comparison is transitive.
+func compareNumbers(a, b *Number) (int, bool) {
+	if a.level() == complexLevel || b.level() == complexLevel {
+		if toComplex(a.value) == toComplex(b.value) {
+			return 0, true
+		}
+		return 0, false
+	}
+
+	if x, ok := a.value.(int); ok {
+		if y, ok := b.value.(int); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+
+	if !a.isExact() || !b.isExact() {
+		x, y := toFloat(a.value), toFloat(b.value)
+		if math.IsNaN(x) || math.IsNaN(y) {
+			return 0, false
+		}
+		if math.IsInf(x, 0) || math.IsInf(y, 0) {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+	return toRat(a.exact().value).Cmp(toRat(b.exact().value)), true
+}
+
+// Numbers are eqv? if they have the same exactness and value.
+// Inexact numbers are compared by their bits, so that 0.0 and -0.0 are distinguished.
+func areEqvNumbers(a, b *Number) bool {
+	if a.isExact() != b.isExact() {
+		return false
+	} else if a.isExact() {
+		result, _ := compareNumbers(a, b)
+		return result == 0
+	}
+
+	x, y := toComplex(a.value), toComplex(b.value)
+	return a.level() == b.level() &&
+		math.Float64bits(real(x)) == math.Float64bits(real(y)) &&
+		math.Float64bits(imag(x)) == math.Float64bits(imag(y))
+}
+
+// Returns the text of this number in radix.
+// An inexact number can be written only in decimal.
+func (n *Number) text(radix int) string {
+	switch n.value.(type) {
+	case int:
+		return strconv.FormatInt(int64(n.value.(int)), radix)
+	case *big.Int:
+		return n.value.(*big.Int).Text(radix)
+	case *big.Rat:
+		return fmt.Sprintf("%s/%s", n.value.(*big.Rat).Num().Text(radix), n.value.(*big.Rat).Denom().Text(radix))
+	default:
+		if radix != 10 {
+			raiseError(errorKindWrongType, "inexact number cannot be written in radix %d: %s", radix, n)
+		}
+		return n.String()
+	}
+}
+
+func (n *Number) isZero() bool {
+	if n.level() == complexLevel {
+		return false
+	}
+	ordering, ok := compareNumbers(n, NewNumber(0))
+	return ok && ordering == 0
+}
+
+// Returns the sign of a real number, or 0 for NaN.
+func (n *Number) sign() int {
+	ordering, _ := compareNumbers(n, NewNumber(0))
+	return ordering
+}
+
+func (n *Number) isOdd() bool {
+	switch n.value.(type) {
+	case int:
+		return n.value.(int)%2 != 0
+	case *big.Int:
+		return n.value.(*big.Int).Bit(0) != 0
+	default:
+		return math.Mod(toFloat(n.value), 2) != 0
+	}
+}
+
+func (n *Number) negate() *Number {
+	return subtraction.apply(NewNumber(0), n)
+}
+
+func (n *Number) abs() *Number {
+	if n.sign() < 0 {
+		return n.negate()
+	}
+	return n
+}
+
+// Divide integers, and returns the quotient and the remainder.
+// If floor is true, the quotient is rounded toward negative infinity, otherwise toward zero.
+func divideIntegers(a, b *Number, floor bool) (*Number, *Number) {
+	assertInteger(a)
+	assertInteger(b)
+	if b.isZero() {
+		raiseError(errorKindDivisionByZero, "attempt to divide by zero")
+	}
+
+	if !a.isExact() || !b.isExact() {
+		x, y := toFloat(a.value), toFloat(b.value)
+		q, r := math.Trunc(x/y), math.Mod(x, y)
+		if floor && r != 0 && (r < 0) != (y < 0) {
+			q, r = q-1, r+y
+		}
+		return NewNumber(q), NewNumber(r)
+	}
+
+	x, xok := a.value.(int)
+	y, yok := b.value.(int)
+	if xok && yok && !(x == math.MinInt && y == -1) {
+		q, r := x/y, x%y
+		if floor && r != 0 && (r < 0) != (y < 0) {
+			q, r = q-1, r+y
+		}
+		return NewNumber(q), NewNumber(r)
+	}
+
+	bigY := toBigInt(b.value)
+	q, r := new(big.Int).QuoRem(toBigInt(a.value), bigY, new(big.Int))
+	if floor && r.Sign() != 0 && (r.Sign() < 0) != (bigY.Sign() < 0) {
+		q.Sub(q, big.NewInt(1))
+		r.Add(r, bigY)
+	}
+	return NewNumber(q), NewNumber(r)
+}
+
+// Returns the greatest common divisor of integers, which is exact only if both are exact.
+func gcdNumbers(a, b *Number) *Number {
+	assertInteger(a)
+	assertInteger(b)
+
+	result := NewNumber(new(big.Int).GCD(nil, nil, toBigInt(a.exact().value), toBigInt(b.exact().value)))
+	if !a.isExact() || !b.isExact() {
+		return result.inexact()
+	}
+	return result
+}
+
+const (
+	roundFloor = iota
+	roundCeiling
+	roundTruncate
+	roundNearest // to even on a tie
+)
+
+// Round a real number to an integer, which has the same exactness.
+func (n *Number) round(mode int) *Number {
+	switch n.value.(type) {
+	case int, *big.Int:
+		return n
+	case float64:
+		switch value := n.value.(float64); mode {
+		case roundFloor:
+			return NewNumber(math.Floor(value))
+		case roundCeiling:
+			return NewNumber(math.Ceil(value))
+		case roundTruncate:
+			return NewNumber(math.Trunc(value))
+		default:
+			return NewNumber(math.RoundToEven(value))
+		}
+	case *big.Rat:
+		// the denominator is positive, so that Euclidean division is floor division
+		rational := n.value.(*big.Rat)
+		numerator, denominator := rational.Num(), rational.Denom()
+		switch mode {
+		case roundFloor:
+			return NewNumber(new(big.Int).Div(numerator, denominator))
+		case roundCeiling:
+			return n.negate().round(roundFloor).negate()
+		case roundTruncate:
+			return NewNumber(new(big.Int).Quo(numerator, denominator))
+		default:
+			// floor(x + 1/2) is rounded up on a tie, which is rounded to even
+			twice := new(big.Int).Lsh(denominator, 1)
+			quotient, modulus := new(big.Int).DivMod(new(big.Int).Add(new(big.Int).Lsh(numerator, 1), denominator), twice, new(big.Int))
+			if modulus.Sign() == 0 && quotient.Bit(0) != 0 {
+				quotient.Sub(quotient, big.NewInt(1))
+			}
+			return NewNumber(quotient)
+		}
+	default:
+		typeError("real number required, but got %s", n)
+		return nil
+	}
+}
+
+// Returns the square root, which is exact if this is an exact square of rational.
+func (n *Number) sqrt() *Number {
+	if n.isExact() {
+		rational := toRat(n.abs().value)
+		numerator, denominator := new(big.Int).Sqrt(rational.Num()), new(big.Int).Sqrt(rational.Denom())
+		if new(big.Int).Mul(numerator, numerator).Cmp(rational.Num()) == 0 &&
+			new(big.Int).Mul(denominator, denominator).Cmp(rational.Denom()) == 0 {
+			root := NewNumber(new(big.Rat).SetFrac(numerator, denominator))
+			if n.sign() < 0 {
+				return NewNumber(complex(0, toFloat(root.value)))
+			}
+			return root
+		}
+	}
+	return n.transcend(math.Sqrt, cmplx.Sqrt, func(x float64) bool { return x >= 0 })
+}
+
+// Returns base raised to the power of exponent, which is exact if base is exact
+// and exponent is an exact integer.
+func expt(base, exponent *Number) *Number {
+	if base.isExact() && exponent.level() == integerLevel {
+		if base.isZero() && exponent.sign() < 0 {
+			raiseError(errorKindDivisionByZero, "attempt to divide by zero")
+		}
+		power := new(big.Int).Abs(toBigInt(exponent.value))
+		rational := toRat(base.value)
+		result := new(big.Rat).SetFrac(
+			new(big.Int).Exp(rational.Num(), power, nil),
+			new(big.Int).Exp(rational.Denom(), power, nil),
+		)
+		if exponent.sign() < 0 {
+			result.Inv(result)
+		}
+		return NewNumber(result)
+	}
+
+	if base.isReal() && exponent.isReal() {
+		x, y := toFloat(base.value), toFloat(exponent.value)
+		if x >= 0 || y == math.Trunc(y) {
+			return NewNumber(math.Pow(x, y))
+		}
+	}
+	return NewNumber(cmplx.Pow(toComplex(base.value), toComplex(exponent.value)))
+}
+
+// Apply an inexact function to this number. If the real function is not
+// defined for this number, e.g. (log -1), the complex function is applied.
+func (n *Number) transcend(realFunc func(float64) float64, complexFunc func(complex128) complex128, inDomain func(float64) bool) *Number {
+	if n.isReal() {
+		if x := toFloat(n.value); inDomain == nil || inDomain(x) {
+			return NewNumber(realFunc(x))
+		}
+	}
+	return NewNumber(complexFunc(toComplex(n.value)))
+}
@@ -4,12 +4,17 @@
 
 package scheme
 
+import (
+	"fmt"
+)
+
 type Object interface {
 	Parent() Object
 	Bounder() *Variable
+	Location() *Location
 	setParent(Object)
 	setBounder(*Variable)
-	Eval() Object
+	setLocation(*Location)
 	String() string
 	isNumber() bool
 	isBoolean() bool
@@ -21,25 +26,26 @@ type Object interface {
 	isSymbol() bool
 	isSyntax() bool
 	isString() bool
+	isChar() bool
+	isVector() bool
+	isHashTable() bool
 	isVariable() bool
 	isApplication() bool
-	define(string, Object)
-	set(string, Object)
-	scopedBinding() Binding
-	binding() Binding
-	boundedObject(string) Object
 }
 
 type Binding map[string]Object
 
 type ObjectBase struct {
-	parent  Object
-	bounder *Variable // Variable.Eval() sets itself into this
+	parent   Object
+	bounder  *Variable // the variable which is bound to this object at first
+	location *Location // where the expression appears in source code
 }
 
-func (o *ObjectBase) Eval() Object {
-	runtimeError("This object's Eval() is not implemented yet.")
-	return nil
+// Location is a position in source code.
+type Location struct {
+	File   string // empty for source code which is not loaded from file
+	Line   int
+	Column int
 }
 
 func (o *ObjectBase) String() string {
@@ -87,16 +93,24 @@ func (o *ObjectBase) isString() bool {
 	return false
 }
 
-func (o *ObjectBase) isVariable() bool {
+func (o *ObjectBase) isChar() bool {
 	return false
 }
 
-func (o *ObjectBase) isApplication() bool {
+func (o *ObjectBase) isVector() bool {
+	return false
+}
+
+func (o *ObjectBase) isHashTable() bool {
 	return false
 }
 
-func (o *ObjectBase) binding() Binding {
-	return Binding{}
+func (o *ObjectBase) isVariable() bool {
+	return false
+}
+
+func (o *ObjectBase) isApplication() bool {
+	return false
 }
 
 func (o *ObjectBase) Parent() Object {
@@ -107,6 +121,10 @@ func (o *ObjectBase) Bounder() *Variable {
 	return o.bounder
 }
 
+func (o *ObjectBase) Location() *Location {
+	return o.location
+}
+
 func (o *ObjectBase) setParent(parent Object) {
 	o.parent = parent
 }
@@ -115,52 +133,13 @@ func (o *ObjectBase) setBounder(bounder *Variable) {
 	o.bounder = bounder
 }
 
-func (o *ObjectBase) scopedBinding() (scopedBinding Binding) {
-	scopedBinding = make(Binding)
-	parent := o.Parent()
-
-	for parent != nil {
-		for identifier, object := range parent.binding() {
-			if scopedBinding[identifier] == nil {
-				scopedBinding[identifier] = object
-			}
-		}
-		parent = parent.Parent()
-	}
-	return
-}
-
-// Define variable in the most inner closure
-func (o *ObjectBase) define(identifier string, object Object) {
-	if o.parent == nil {
-		runtimeError("Bind called for object whose parent is nil")
-	} else {
-		o.parent.define(identifier, object)
-	}
-}
-
-// This is for set! syntax.
-// Update the variable's value when it is defined.
-func (o *ObjectBase) set(identifier string, object Object) {
-	if o.parent == nil {
-		runtimeError("symbol not defined")
-	} else {
-		o.parent.set(identifier, object)
-	}
+func (o *ObjectBase) setLocation(location *Location) {
+	o.location = location
 }
 
-func (o *ObjectBase) boundedObject(identifier string) Object {
-	scopedBinding := make(Binding)
-	parent := o.Parent()
-
-	for parent != nil {
-		for identifier, object := range parent.binding() {
-			if scopedBinding[identifier] == nil {
-				scopedBinding[identifier] = object
-			}
-		}
-		parent = parent.Parent()
+func (l *Location) String() string {
+	if l.File == "" {
+		return fmt.Sprintf("%d:%d", l.Line, l.Column)
 	}
-
-	return scopedBinding[identifier]
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
 }
@@ -27,30 +27,33 @@ func NewPair(parent Object) *Pair {
 }
 
 func NewList(parent Object, objects ...Object) *Pair {
-	list := NewPair(parent)
-	for _, object := range objects {
-		list.Append(object)
+	if len(objects) == 0 {
+		return NewPair(parent)
 	}
-	return list
-}
 
-func (p *Pair) Eval() Object {
-	return p
+	list := new(Pair)
+	for i := len(objects) - 1; i >= 0; i-- {
+		list = &Pair{Car: objects[i], Cdr: list}
+	}
+	list.setParent(parent)
+	return list
 }
 
 func (p *Pair) String() string {
 	if p.isNull() {
 		return "()"
-	} else if p.isList() {
-		length := p.ListLength()
-		tokens := []string{}
-		for i := 0; i < length; i++ {
-			tokens = append(tokens, p.ElementAt(i).String())
-		}
-		return fmt.Sprintf("(%s)", strings.Join(tokens, " "))
-	} else {
-		return fmt.Sprintf("(%s . %s)", p.Car, p.Cdr)
 	}
+
+	tokens := []string{}
+	var object Object = p
+	for ; object.isPair(); object = object.(*Pair).Cdr {
+		tokens = append(tokens, object.(*Pair).Car.String())
+	}
+	if !object.isNull() {
+		// improper list
+		tokens = append(tokens, ".", object.String())
+	}
+	return fmt.Sprintf("(%s)", strings.Join(tokens, " "))
 }
 
 func (p *Pair) isNull() bool {
@@ -75,7 +78,6 @@ func (p *Pair) isList() bool {
 			return false
 		}
 	}
-	return false
 }
 
 func (p *Pair) Elements() []Object {
@@ -94,7 +96,11 @@ func (p *Pair) Elements() []Object {
 }
 
 func (p *Pair) ElementAt(index int) Object {
-	return p.Elements()[index]
+	pair := p
+	for ; index > 0; index-- {
+		pair = pair.Cdr.(*Pair)
+	}
+	return pair.Car
 }
 
 func (p *Pair) ListLength() int {
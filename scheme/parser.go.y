@@ -1,20 +1,25 @@
 %{
 // Parser is a type to analyse scheme source's syntax.
 // It embeds Lexer to generate tokens from a source code.
-// Parser.Parse() does syntactic analysis and returns scheme object pointer.
+// The grammar reads data such as lists and symbols with their locations,
+// and Parser.Parse() analyzes them into expressions of AST.
 
 package scheme
+
+import (
+	"io"
+)
 %}
 
 %union{
-	objects []Object
-	object  Object
-	token   string
+	objects  []Object
+	object   Object
+	token    string
+	location *Location
 }
 
-%type<objects> program
-%type<object> list
-%type<object> expr
+%type<object> program
+%type<object> slist
 %type<object> sexpr
 %type<object> const
 
@@ -22,65 +27,89 @@ package scheme
 %token<token> NUMBER
 %token<token> BOOLEAN
 %token<token> STRING
+%token<token> CHAR
+%token<token> VECTOR_START
+%token<token> UNQUOTE_SPLICING
+%token DATUM_START
 
 %%
 
 program:
+	slist
 		{
-			$$ = []Object{}
+			if l, ok := yylex.(*Lexer); ok {
+				l.results = $1
+			}
 		}
-	| program expr
+	| DATUM_START sexpr
 		{
-			$$ = append($1, $2)
+			$$ = locatedPair(yylex, $2, $<location>2, Null)
 			if l, ok := yylex.(*Lexer); ok {
 				l.results = $$
 			}
 		}
 
-list:
+slist:
 		{ $$ = Null }
-	| expr list
-		{
-			pair := NewPair(nil)
-			pair.Car = $1
-			pair.Car.setParent(pair)
-			pair.Cdr = $2
-			pair.Cdr.setParent(pair)
-			$$ = pair
-		}
+	| sexpr slist
+		{ $$ = locatedPair(yylex, $1, $<location>1, $2) }
 
-expr:
+sexpr:
 	const
 		{ $$ = $1 }
 	| IDENTIFIER
-		{ $$ = NewVariable($1, nil) }
+		{ $$ = NewSymbol($1) }
 	| '\'' sexpr
-		{ $$ = $2 }
-	| '(' expr list ')'
+		{ $$ = abbreviation(yylex, "quote", $<location>1, $2, $<location>2) }
+	| '`' sexpr
+		{ $$ = abbreviation(yylex, "quasiquote", $<location>1, $2, $<location>2) }
+	| ',' sexpr
+		{ $$ = abbreviation(yylex, "unquote", $<location>1, $2, $<location>2) }
+	| UNQUOTE_SPLICING sexpr
+		{ $$ = abbreviation(yylex, "unquote-splicing", $<location>1, $2, $<location>2) }
+	| '(' slist ')'
 		{
-			app := NewApplication(nil)
-			app.procedure = $2
-			app.procedure.setParent(app)
-			app.arguments = $3
-			app.arguments.setParent(app)
-			$$ = app
+			$$ = $2
+			if !$$.isNull() {
+				$$.setLocation($<location>1)
+			}
+		}
+	| '(' sexpr slist '.' sexpr ')'
+		{
+			$$ = locatedPair(yylex, $2, $<location>2, dottedList($3, $5))
+			$$.setLocation($<location>1)
 		}
-
-sexpr:
-	const
-		{ $$ = $1 }
-	| IDENTIFIER
-		{ $$ = NewSymbol($1) }
-	| '(' list ')'
-		{ $$ = $2 }
 
 const:
 	NUMBER
-		{ $$ = NewNumber($1) }
+		{
+			$$ = NewNumber($1)
+			$$.setLocation($<location>1)
+		}
 	| BOOLEAN
-		{ $$ = NewBoolean($1) }
+		{
+			$$ = NewBoolean($1)
+			$$.setLocation($<location>1)
+		}
 	| STRING
-		{ $$ = NewString($1[1:len($1)-1]) }
+		{
+			$$ = NewString(unescapeString($1, $<location>1))
+			$$.setLocation($<location>1)
+		}
+	| CHAR
+		{
+			$$ = NewChar(unescapeChar($1, $<location>1))
+			$$.setLocation($<location>1)
+		}
+	| VECTOR_START slist ')'
+		{
+			elements := []Object{}
+			if $2.isPair() {
+				elements = $2.(*Pair).Elements()
+			}
+			$$ = NewVector(elements)
+			$$.setLocation($<location>1)
+		}
 	| '(' ')'
 		{ $$ = Null }
 
@@ -94,110 +123,83 @@ func NewParser(source string) *Parser {
 	return &Parser{NewLexer(source)}
 }
 
-func (p *Parser) Parse(parent Object) []Object {
-	p.ensureAvailability()
-	if yyParse(p.Lexer) != 0 {
-		panic("parse error")
-	}
+// Returns a parser whose AST records file name in locations.
+func NewFileParser(file string, source string) *Parser {
+	p := NewParser(source)
+	p.file = file
+	return p
+}
 
-	for _, r := range p.results {
-		r.setParent(parent)
-	}
-	return p.results
+// Returns a parser which reads a datum from reader by ParseDatum.
+func newDatumParser(reader io.Reader) *Parser {
+	p := &Parser{newReaderLexer(reader)}
+	p.datumOnly = true
+	return p
 }
 
-func (p *Parser) parseObject(parent Object) Object {
-	tokenType := p.TokenType()
-	token := p.NextToken()
-
-	switch tokenType {
-	case '(':
-		return p.parseApplication(parent)
-	case '\'':
-		return p.parseSingleQuote(parent)
-	case IntToken:
-		return NewNumber(token, parent)
-	case IdentifierToken:
-		return NewVariable(token, parent)
-	case BooleanToken:
-		return NewBoolean(token, parent)
-	case StringToken:
-		return NewString(token[1:len(token)-1], parent)
-	default:
+// Parse a datum, which is an external representation of an object such as
+// a list, a symbol or a number. Returns nil if source has no more datum.
+func (p *Parser) ParseDatum() Object {
+	if p.TokenType() == EOF {
 		return nil
 	}
-	return nil
+	return p.read().(*Pair).Car
 }
 
-// This is for parsing syntax sugar '*** => (quote ***)
-func (p *Parser) parseSingleQuote(parent Object) Object {
-	if len(p.PeekToken()) == 0 {
-		runtimeError("unterminated quote")
+// Parse source code into expressions of AST, which are analyzed from data in it.
+func (p *Parser) Parse(parent Object) []Object {
+	analyzer := &analyzer{locations: p.locations, quotations: p.quotations}
+	expressions := []Object{}
+	for cell := p.read(); cell.isPair(); cell = cell.(*Pair).Cdr {
+		expressions = append(expressions, analyzer.element(cell.(*Pair), parent))
 	}
-	application := NewApplication(parent)
-	application.procedure = NewVariable("quote", application)
-	application.arguments = NewList(application, p.parseObject(application))
-	return application
+	return expressions
 }
 
-// This function returns *Pair of first object and list from second.
-// Scanner position ends with the next of close parentheses.
-func (p *Parser) parseList(parent Object) Object {
-	pair := NewPair(parent)
-	pair.Car = p.parseObject(pair)
-	if pair.Car == nil {
-		return pair
+// Returns a list of data in source, whose cells are located at the data.
+func (p *Parser) read() Object {
+	if yyParse(p.Lexer) != 0 {
+		panic("parse error")
 	}
-	pair.Cdr = p.parseList(pair).(*Pair)
-	return pair
+	return p.results
 }
 
-func (p *Parser) parseApplication(parent Object) Object {
-	if p.PeekToken() == ")" {
-		p.NextToken()
-		return Null
+// Returns a pair of car and cdr which is located at car.
+// Locations of symbols are recorded by lexer, because a symbol is shared by its occurrences.
+func locatedPair(yylex yyLexer, car Object, location *Location, cdr Object) *Pair {
+	pair := NewPair(nil)
+	pair.setLocation(location)
+	pair.Car = car
+	pair.Car.setParent(pair)
+	pair.Cdr = cdr
+	pair.Cdr.setParent(pair)
+	if l, ok := yylex.(*Lexer); ok && car.isSymbol() {
+		l.locations[pair] = location
 	}
-	application := NewApplication(parent)
-	application.procedure = p.parseObject(application)
-	application.arguments = p.parseList(application)
-
-	return application
+	return pair
 }
 
-func (p *Parser) parseQuotedObject(parent Object) Object {
-	tokenType := p.TokenType()
-	token := p.NextToken()
-
-	switch tokenType {
-	case '(':
-		return p.parseQuotedList(parent)
-	case '\'':
-		return p.parseSingleQuote(parent)
-	case IntToken:
-		return NewNumber(token, parent)
-	case IdentifierToken:
-		return NewSymbol(token)
-	case BooleanToken:
-		return NewBoolean(token, parent)
-	case ')':
-		return nil
-	default:
-		runtimeError("unterminated quote")
-		return nil
+// Returns a list for an abbreviation such as 'datum, which is located at its prefix.
+func abbreviation(yylex yyLexer, keyword string, location *Location, datum Object, datumLocation *Location) Object {
+	list := locatedPair(yylex, NewSymbol(keyword), location, locatedPair(yylex, datum, datumLocation, Null))
+	if l, ok := yylex.(*Lexer); ok && keyword == "quote" {
+		l.quotations[list] = true
 	}
+	return list
 }
 
-func (p *Parser) parseQuotedList(parent Object) Object {
-	pair := NewPair(parent)
-	pair.Car = p.parseQuotedObject(pair)
-	if pair.Car == nil {
-		return pair
+// Returns a list whose last cdr is replaced with tail.
+// If list is empty, tail itself is returned.
+func dottedList(list Object, tail Object) Object {
+	if list.isNull() {
+		return tail
 	}
-	pair.Cdr = p.parseQuotedList(pair).(*Pair)
-	return pair
-}
 
-func (p *Parser) ensureAvailability() {
-	// Error message will be printed by interpreter
-	recover()
+	pair := list.(*Pair)
+	for !pair.Cdr.isNull() {
+		pair = pair.Cdr.(*Pair)
+	}
+	pair.Cdr = tail
+	pair.Cdr.setParent(pair)
+	return list
 }
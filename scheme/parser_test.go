@@ -35,6 +35,13 @@ var easyParserTests = []easyParserTest{
 	{"(string-append)", "(string-append)"},
 	{"((lambda (x y z) (* (+ x y) z)) 1 2 3)", "((lambda (x y z) (* (+ x y) z)) 1 2 3)"},
 	{"\"a b\"", "\"a b\""},
+	{"`(a ,b ,@c)", "`(a ,b ,@c)"},
+	{"(quasiquote (unquote x))", "`,x"},
+	{"'(1 . 2)", "(1 . 2)"},
+	{"'(1 2 . 3)", "(1 2 . 3)"},
+	{"'(1 . (2 . (3 . ())))", "(1 2 3)"},
+	{"'((1 . 2) . 3)", "((1 . 2) . 3)"},
+	{"(lambda (x . y) y)", "(lambda (x . y) y)"},
 }
 
 var deepParserTests = []deepParserTest{
@@ -102,6 +109,7 @@ func TestParser(t *testing.T) {
 		i.Peek()
 		objects := i.Parse(nil)
 		object := objects[0]
+		clearLocations(object)
 
 		if !reflect.DeepEqual(object, test.result) {
 			t.Errorf(
@@ -114,3 +122,47 @@ func TestParser(t *testing.T) {
 		}
 	}
 }
+
+func TestParserLocation(t *testing.T) {
+	parser := NewFileParser("test.scm", "(define x\n  (+ 1 foo))\n'(a b)")
+	parser.Peek()
+	objects := parser.Parse(nil)
+
+	application := objects[0].(*Application)
+	arguments := application.arguments.(*Pair).Elements()
+	nested := arguments[1].(*Application)
+	tests := []struct {
+		object   Object
+		location string
+	}{
+		{application, "test.scm:1:1"},
+		{application.procedure, "test.scm:1:2"},
+		{arguments[0], "test.scm:1:9"},
+		{nested, "test.scm:2:3"},
+		{nested.arguments.(*Pair).Car, "test.scm:2:6"},
+		{nested.arguments.(*Pair).ElementAt(1), "test.scm:2:8"},
+		{objects[1], "test.scm:3:2"},
+	}
+	for _, test := range tests {
+		if location := test.object.Location(); location == nil || location.String() != test.location {
+			t.Errorf("location of %s => %v; want %s", test.object, location, test.location)
+		}
+	}
+}
+
+// Clear locations in AST to compare it with expected one.
+func clearLocations(object Object) {
+	if object == nil || object == Null {
+		return
+	}
+	object.setLocation(nil)
+
+	switch object.(type) {
+	case *Application:
+		clearLocations(object.(*Application).procedure)
+		clearLocations(object.(*Application).arguments)
+	case *Pair:
+		clearLocations(object.(*Pair).Car)
+		clearLocations(object.(*Pair).Cdr)
+	}
+}
@@ -0,0 +1,286 @@
+// Port is a type for scheme port object, which reads characters from or
+// writes characters to a file, standard streams or a string.
+
+package scheme
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/scanner"
+	"unicode/utf8"
+)
+
+var (
+	// Ports of standard streams, which are current ports when an evaluation starts.
+	standardPorts = ports{
+		input:  NewInputPort("stdin", os.Stdin),
+		output: NewOutputPort("stdout", os.Stdout),
+		error:  NewOutputPort("stderr", os.Stderr),
+	}
+
+	eofObject = Object(&EOFObject{})
+)
+
+// Current ports of an evaluation.
+type ports struct {
+	input, output, error *Port
+}
+
+type Port struct {
+	ObjectBase
+	name   string
+	reader *bufio.Reader // nil for output port
+	writer io.Writer     // nil for input port
+	closer io.Closer     // nil for port which is not closed by close-port
+	closed bool
+}
+
+// EOFObject is returned by input procedures at the end of input.
+type EOFObject struct {
+	ObjectBase
+}
+
+func NewInputPort(name string, reader io.Reader) *Port {
+	port := &Port{name: name, reader: bufio.NewReader(reader)}
+	if file, ok := reader.(*os.File); ok && !isStandardFile(file) {
+		port.closer = file
+	}
+	return port
+}
+
+// An output port to a file is buffered, and flushed when it is closed.
+func NewOutputPort(name string, writer io.Writer) *Port {
+	port := &Port{name: name, writer: writer}
+	if file, ok := writer.(*os.File); ok && !isStandardFile(file) {
+		port.writer, port.closer = bufio.NewWriter(file), file
+	}
+	return port
+}
+
+func isStandardFile(file *os.File) bool {
+	return file == os.Stdin || file == os.Stdout || file == os.Stderr
+}
+
+func openInputFile(path string) *Port {
+	file, err := os.Open(path)
+	if err != nil {
+		fileError("cannot open input file: %s", NewString(path))
+	}
+	return NewInputPort(path, file)
+}
+
+func openOutputFile(path string) *Port {
+	file, err := os.Create(path)
+	if err != nil {
+		fileError("cannot open output file: %s", NewString(path))
+	}
+	return NewOutputPort(path, file)
+}
+
+func openInputString(text string) *Port {
+	return NewInputPort("string", strings.NewReader(text))
+}
+
+// Characters written to a string port are accumulated in memory, and got by outputString.
+func openOutputString() *Port {
+	return NewOutputPort("string", &strings.Builder{})
+}
+
+func (p *Port) String() string {
+	if p.isInputPort() {
+		return fmt.Sprintf("#<input-port %s>", p.name)
+	}
+	return fmt.Sprintf("#<output-port %s>", p.name)
+}
+
+func (p *Port) outputString() string {
+	builder, ok := p.writer.(*strings.Builder)
+	if !ok {
+		typeError("string output port required, but got %s", p)
+	}
+	return builder.String()
+}
+
+func (p *Port) isInputPort() bool {
+	return p.reader != nil
+}
+
+func (p *Port) isOutputPort() bool {
+	return p.writer != nil
+}
+
+func (p *Port) assertOpen() {
+	if p.closed {
+		runtimeError("port is already closed: %s", p)
+	}
+}
+
+// Read a character, or returns false at the end of input.
+func (p *Port) readRune() (rune, bool) {
+	p.assertOpen()
+	char, _, err := p.reader.ReadRune()
+	if err == io.EOF {
+		return 0, false
+	} else if err != nil {
+		fileError("cannot read from %s: %s", p, NewString(err.Error()))
+	}
+	return char, true
+}
+
+func (p *Port) peekRune() (rune, bool) {
+	char, ok := p.readRune()
+	if ok {
+		p.reader.UnreadRune()
+	}
+	return char, ok
+}
+
+// Read a line without its line terminator, or returns false at the end of input.
+func (p *Port) readLine() (string, bool) {
+	p.assertOpen()
+	line, err := p.reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		fileError("cannot read from %s: %s", p, NewString(err.Error()))
+	} else if err == io.EOF && line == "" {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), true
+}
+
+// Read a datum by the parser of scheme source, or returns the eof object at the end of input.
+// Characters following the datum are left in the port.
+func (p *Port) readDatum() Object {
+	p.assertOpen()
+	parser := newDatumParser(runeReader{p.reader})
+	datum := parser.ParseDatum()
+	if parser.Peek() != scanner.EOF {
+		// the scanner has read a character after the datum
+		p.reader.UnreadRune()
+	}
+
+	if datum == nil {
+		return eofObject
+	}
+	return datum
+}
+
+func (p *Port) write(text string) {
+	p.assertOpen()
+	if _, err := io.WriteString(p.writer, text); err != nil {
+		fileError("cannot write to %s: %s", p, NewString(err.Error()))
+	}
+}
+
+func (p *Port) flush() {
+	p.assertOpen()
+	if writer, ok := p.writer.(*bufio.Writer); ok {
+		writer.Flush()
+	}
+}
+
+// Close the port. Closing a closed port or a standard port has no effect.
+func (p *Port) close() {
+	if p.closed || p.isStandardPort() {
+		return
+	}
+	if p.isOutputPort() {
+		p.flush()
+	}
+	if p.closer != nil {
+		p.closer.Close()
+	}
+	p.closed = true
+}
+
+func (p *Port) isStandardPort() bool {
+	return p == standardPorts.input || p == standardPorts.output || p == standardPorts.error
+}
+
+// A reader which returns a character at a time, so that a scanner does not read ahead
+// more than a character.
+type runeReader struct {
+	reader *bufio.Reader
+}
+
+func (r runeReader) Read(buffer []byte) (int, error) {
+	char, _, err := r.reader.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	return utf8.EncodeRune(buffer, char), nil
+}
+
+func (e *EOFObject) String() string {
+	return "#<eof>"
+}
+
+func currentInputPort(environment *Environment) *Port {
+	return environment.evaluation.ports.input
+}
+
+func currentOutputPort(environment *Environment) *Port {
+	return environment.evaluation.ports.output
+}
+
+func currentErrorPort(environment *Environment) *Port {
+	return environment.evaluation.ports.error
+}
+
+// Returns an input port given as an optional argument, or the current input port.
+func inputPortArgument(objects []Object, environment *Environment) *Port {
+	if len(objects) == 0 {
+		return currentInputPort(environment)
+	}
+	if port, ok := objects[0].(*Port); !ok || !port.isInputPort() {
+		typeError("input port required, but got %s", objects[0])
+	}
+	return objects[0].(*Port)
+}
+
+// Returns an output port given as an optional argument, or the current output port.
+func outputPortArgument(objects []Object, environment *Environment) *Port {
+	if len(objects) == 0 {
+		return currentOutputPort(environment)
+	}
+	if port, ok := objects[0].(*Port); !ok || !port.isOutputPort() {
+		typeError("output port required, but got %s", objects[0])
+	}
+	return objects[0].(*Port)
+}
+
+// Call thunk with port, which is the current input or output port while thunk is called.
+// The port is closed when thunk returns.
+func withPort(port *Port, thunk Object, environment *Environment) Object {
+	evaluation := environment.evaluation
+	saved := evaluation.ports
+	if port.isInputPort() {
+		evaluation.ports.input = port
+	} else {
+		evaluation.ports.output = port
+	}
+
+	restore := func() {
+		evaluation.ports = saved
+	}
+	defer restore()
+	return evalThen(func(environment *Environment) Object {
+		return applyProcedure(thunk, []Object{}, environment)
+	}, environment, func(result Object, environment *Environment) Object {
+		restore()
+		port.close()
+		return result
+	})
+}
+
+// Call procedure with port, and close the port when procedure returns.
+func callWithPort(port *Port, procedure Object, environment *Environment) Object {
+	return evalThen(func(environment *Environment) Object {
+		return applyProcedure(procedure, []Object{port}, environment)
+	}, environment, func(result Object, environment *Environment) Object {
+		port.close()
+		return result
+	})
+}
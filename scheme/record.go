@@ -0,0 +1,105 @@
+// Record is a type for instances of record types, which are defined by
+// define-record-type. A record type is created each time the form is
+// evaluated, and its procedures accept only records of the type.
+
+package scheme
+
+import (
+	"fmt"
+	"strings"
+)
+
+type RecordType struct {
+	ObjectBase
+	name   string
+	fields []string
+}
+
+type Record struct {
+	ObjectBase
+	recordType *RecordType
+	fields     []Object
+}
+
+// A type name such as <point> is written without angle brackets.
+func NewRecordType(name string, fields []string) *RecordType {
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") && len(name) > 2 {
+		name = name[1 : len(name)-1]
+	}
+	return &RecordType{name: name, fields: fields}
+}
+
+func (t *RecordType) String() string {
+	return fmt.Sprintf("#<record-type %s>", t.name)
+}
+
+func (t *RecordType) fieldIndex(field string) int {
+	for index, name := range t.fields {
+		if name == field {
+			return index
+		}
+	}
+	return -1
+}
+
+func (t *RecordType) assertRecord(object Object) *Record {
+	if record, ok := object.(*Record); !ok || record.recordType != t {
+		typeError("%s required, but got %s", t.name, object)
+	}
+	return object.(*Record)
+}
+
+// Returns a constructor which initializes fields by its arguments in order.
+// The other fields are left unspecified.
+func (t *RecordType) constructor(fields []string) *Subroutine {
+	indexes := []int{}
+	for _, field := range fields {
+		indexes = append(indexes, t.fieldIndex(field))
+	}
+
+	return NewSubroutine(func(s *Subroutine, arguments Object, environment *Environment) Object {
+		assertListEqual(arguments, len(indexes))
+
+		values := make([]Object, len(t.fields))
+		for index := range values {
+			values[index] = undef
+		}
+		for index, argument := range arguments.(*Pair).Elements() {
+			values[indexes[index]] = argument
+		}
+		return &Record{recordType: t, fields: values}
+	})
+}
+
+func (t *RecordType) predicate() *Subroutine {
+	return NewSubroutine(func(s *Subroutine, arguments Object, environment *Environment) Object {
+		assertListEqual(arguments, 1)
+
+		record, ok := arguments.(*Pair).ElementAt(0).(*Record)
+		return NewBoolean(ok && record.recordType == t)
+	})
+}
+
+func (t *RecordType) accessor(field string) *Subroutine {
+	index := t.fieldIndex(field)
+	return NewSubroutine(func(s *Subroutine, arguments Object, environment *Environment) Object {
+		assertListEqual(arguments, 1)
+
+		return t.assertRecord(arguments.(*Pair).ElementAt(0)).fields[index]
+	})
+}
+
+func (t *RecordType) modifier(field string) *Subroutine {
+	index := t.fieldIndex(field)
+	return NewSubroutine(func(s *Subroutine, arguments Object, environment *Environment) Object {
+		assertListEqual(arguments, 2)
+
+		objects := arguments.(*Pair).Elements()
+		t.assertRecord(objects[0]).fields[index] = objects[1]
+		return undef
+	})
+}
+
+func (r *Record) String() string {
+	return fmt.Sprintf("#<%s>", r.recordType.name)
+}
@@ -0,0 +1,171 @@
+// Scope is a compile-time frame of variable names.
+// Each local Scope corresponds to an Environment frame which is created
+// at runtime, so that a local variable is resolved to a pair of depth and
+// index when it is compiled. The root Scope corresponds to the global
+// environment, whose variables are looked up by name.
+
+package scheme
+
+type Scope struct {
+	parent      *Scope
+	names       []string       // identifiers of local slots
+	macros      map[int]Object // transformers bound by let-syntax family at compile time
+	environment *Environment   // global environment, only for root scope
+}
+
+// Reference is a location where a variable is bound.
+type Reference struct {
+	local       bool
+	depth       int      // number of frames to go up for a local variable
+	index       int      // slot in the frame of a local variable
+	identifiers []string // names to look up in global environment in order
+}
+
+func NewGlobalScope(environment *Environment) *Scope {
+	return &Scope{environment: environment}
+}
+
+func NewScope(parent *Scope, variables ...Object) *Scope {
+	scope := &Scope{parent: parent}
+	for _, variable := range variables {
+		scope.names = append(scope.names, variableName(variable))
+	}
+	return scope
+}
+
+func (s *Scope) isGlobal() bool {
+	return s.parent == nil
+}
+
+// Returns the global environment of this scope.
+func (s *Scope) global() *Environment {
+	scope := s
+	for !scope.isGlobal() {
+		scope = scope.parent
+	}
+	return scope.environment
+}
+
+// Returns the number of local slots.
+func (s *Scope) size() int {
+	return len(s.names)
+}
+
+// Returns index of the slot named identifier in this frame, or -1.
+func (s *Scope) index(identifier string) int {
+	for index := len(s.names) - 1; index >= 0; index-- {
+		if s.names[index] == identifier {
+			return index
+		}
+	}
+	return -1
+}
+
+// Returns index of the slot named identifier, which is added if it does not exist.
+func (s *Scope) allocate(identifier string) int {
+	if index := s.index(identifier); index >= 0 {
+		return index
+	}
+	s.names = append(s.names, identifier)
+	return len(s.names) - 1
+}
+
+// Bind transformer to identifier at compile time.
+func (s *Scope) defineMacro(identifier string, transformer Object) int {
+	index := s.allocate(identifier)
+	if s.macros == nil {
+		s.macros = make(map[int]Object)
+	}
+	s.macros[index] = transformer
+	return index
+}
+
+// Returns the number of frames from this scope to ancestor, or -1.
+func (s *Scope) distance(ancestor *Scope) int {
+	depth := 0
+	for scope := s; !scope.isGlobal(); scope = scope.parent {
+		if scope == ancestor {
+			return depth
+		}
+		depth++
+	}
+	return -1
+}
+
+// Resolve identifier, which is *Variable, *Symbol or *Alias, to its binding.
+// A free alias is resolved by its original name in the scope where the macro is defined.
+func (s *Scope) resolve(identifier Object) *Reference {
+	name, alias := identifierBinding(identifier)
+
+	depth := 0
+	for scope := s; !scope.isGlobal(); scope = scope.parent {
+		if index := scope.index(name); index >= 0 {
+			return &Reference{local: true, depth: depth, index: index}
+		}
+		depth++
+	}
+
+	if alias != nil && alias.scope != nil {
+		reference := alias.scope.resolve(alias.original)
+		if !reference.local {
+			reference.identifiers = append([]string{name}, reference.identifiers...)
+			return reference
+		} else if distance := s.distance(alias.scope); distance >= 0 {
+			reference.depth += distance
+			return reference
+		}
+	}
+	return &Reference{identifiers: []string{name}}
+}
+
+// Returns the object which identifier refers at compile time: a transformer
+// bound by let-syntax family or the value of global variable.
+// Returns nil for a local variable, whose value is not known until runtime.
+func (s *Scope) staticValue(identifier Object) Object {
+	reference := s.resolve(identifier)
+	if reference.local {
+		return s.ancestor(reference.depth).macros[reference.index]
+	}
+	return s.global().lookup(reference.identifiers...)
+}
+
+// Returns true if identifier in this scope and other identifier in other scope
+// refer to the same binding. Free identifiers are the same if their names are.
+func (s *Scope) sameBinding(identifier Object, other *Scope, otherIdentifier Object) bool {
+	a, b := s.resolve(identifier), other.resolve(otherIdentifier)
+	if a.local != b.local {
+		return false
+	} else if a.local {
+		return s.ancestor(a.depth) == other.ancestor(b.depth) && a.index == b.index
+	}
+	return a.identifiers[len(a.identifiers)-1] == b.identifiers[len(b.identifiers)-1]
+}
+
+// Returns the scope which is depth frames up from this scope.
+func (s *Scope) ancestor(depth int) *Scope {
+	scope := s
+	for ; depth > 0; depth-- {
+		scope = scope.parent
+	}
+	return scope
+}
+
+// Returns the identifier by which a binding form binds variable.
+func variableName(variable Object) string {
+	if variable.isVariable() {
+		return variable.(*Variable).identifier
+	}
+	return ""
+}
+
+// Returns the name and the alias of an identifier.
+func identifierBinding(identifier Object) (string, *Alias) {
+	switch identifier.(type) {
+	case *Variable:
+		return identifier.(*Variable).identifier, identifier.(*Variable).alias
+	case *Alias:
+		return identifier.(*Alias).identifier, identifier.(*Alias)
+	default:
+		return identifierName(identifier), nil
+	}
+}
@@ -5,11 +5,14 @@ package scheme
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type String struct {
 	ObjectBase
-	text string
+	runes []rune // characters indexed by code point, which are mutable in place
 }
 
 func NewString(object interface{}, options ...Object) *String {
@@ -23,20 +26,99 @@ func NewString(object interface{}, options ...Object) *String {
 		runtimeError("Unexpected conversion")
 	}
 	if len(options) > 0 {
-		return &String{ObjectBase: ObjectBase{parent: options[0]}, text: text}
+		return &String{ObjectBase: ObjectBase{parent: options[0]}, runes: []rune(text)}
 	} else {
-		return &String{text: text}
+		return &String{runes: []rune(text)}
 	}
 }
 
-func (s *String) Eval() Object {
-	return s
+// Returns a string literal, where special characters are escaped.
+func (s *String) String() string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for _, char := range s.runes {
+		switch char {
+		case '"', '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(char)
+		case '\n':
+			builder.WriteString("\\n")
+		case '\t':
+			builder.WriteString("\\t")
+		case '\r':
+			builder.WriteString("\\r")
+		default:
+			if unicode.IsControl(char) {
+				fmt.Fprintf(&builder, "\\x%x;", char)
+			} else {
+				builder.WriteRune(char)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
 }
 
-func (s *String) String() string {
-	return fmt.Sprintf("\"%s\"", s.text)
+// Returns characters of string as Go's string.
+func (s *String) text() string {
+	return string(s.runes)
 }
 
 func (s *String) isString() bool {
 	return true
 }
+
+// Returns a function which tests a character by a character or a predicate.
+func charMatcher(object Object, environment *Environment) func(rune) bool {
+	if object.isChar() {
+		return func(char rune) bool { return char == object.(*Char).value }
+	}
+	assertProcedure(object)
+	return func(char rune) bool {
+		return isTrue(applyProcedure(object, []Object{NewChar(char)}, environment))
+	}
+}
+
+// Split text at each character which matches, keeping empty fields.
+func splitFunc(text string, match func(rune) bool) []string {
+	fields, start := []string{}, 0
+	for index, char := range text {
+		if match(char) {
+			fields = append(fields, text[start:index])
+			start = index + utf8.RuneLen(char)
+		}
+	}
+	return append(fields, text[start:])
+}
+
+// Returns the index of pattern in runes from start, or #f.
+func searchString(runes []rune, pattern string, start int) Object {
+	index := strings.Index(string(runes[start:]), pattern)
+	if index < 0 {
+		return NewBoolean(false)
+	}
+	return NewNumber(start + utf8.RuneCountInString(string(runes[start:])[:index]))
+}
+
+// Returns lists of characters at each index of strings, up to the shortest one.
+func stringArgumentLists(strs []Object) [][]Object {
+	assertObjectsType(strs, "string")
+
+	runesList := [][]rune{}
+	length := -1
+	for _, str := range strs {
+		runes := str.(*String).runes
+		if length < 0 || len(runes) < length {
+			length = len(runes)
+		}
+		runesList = append(runesList, runes)
+	}
+
+	argumentLists := make([][]Object, length)
+	for index := range argumentLists {
+		for _, runes := range runesList {
+			argumentLists[index] = append(argumentLists[index], NewChar(runes[index]))
+		}
+	}
+	return argumentLists
+}
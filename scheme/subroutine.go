@@ -4,14 +4,19 @@ package scheme
 
 import (
 	"fmt"
+	"math"
+	"strings"
+	"unicode"
 )
 
 type Subroutine struct {
 	ObjectBase
-	function func(*Subroutine, Object) Object
+	function func(*Subroutine, Object, *Environment) Object
 }
 
-func NewSubroutine(function func(*Subroutine, Object) Object) *Subroutine {
+// Subroutine's function receives a list of evaluated arguments and
+// the environment where it is called.
+func NewSubroutine(function func(*Subroutine, Object, *Environment) Object) *Subroutine {
 	return &Subroutine{function: function}
 }
 
@@ -19,12 +24,11 @@ func (s *Subroutine) String() string {
 	return fmt.Sprintf("#<subr %s>", s.Bounder())
 }
 
-func (s *Subroutine) Eval() Object {
-	return s
-}
-
-func (s *Subroutine) Invoke(argument Object) Object {
-	return s.function(s, argument)
+// Call subroutine with a list of evaluated arguments.
+// Built-in errors raised in subroutine are counted by the evaluation of environment.
+func (s *Subroutine) Call(arguments Object, environment *Environment) Object {
+	defer func() { pend(recover(), environment.evaluation) }()
+	return s.function(s, arguments, environment)
 }
 
 func (s *Subroutine) isProcedure() bool {
@@ -34,22 +38,161 @@ func (s *Subroutine) isProcedure() bool {
 func (s *Subroutine) booleanByFunc(arguments Object, typeCheckFunc func(Object) bool) Object {
 	assertListEqual(arguments, 1)
 
-	object := arguments.(*Pair).ElementAt(0).Eval()
+	object := arguments.(*Pair).ElementAt(0)
 	return NewBoolean(typeCheckFunc(object))
 }
 
-func (s *Subroutine) compareNumbers(arguments Object, compareFunc func(int, int) bool) Object {
+// Compare each adjacent pair of numbers by compareFunc, which receives the
+// result of compareNumbers. Unordered numbers, such as NaN, are never compared.
+func (s *Subroutine) compareNumbers(arguments Object, compareFunc func(int) bool) Object {
 	assertListMinimum(arguments, 2)
 
-	numbers := evaledObjects(arguments.(*Pair).Elements())
+	numbers := arguments.(*Pair).Elements()
+	assertObjectsType(numbers, "number")
+
+	result := true
+	for index, number := range numbers[1:] {
+		ordering, ok := compareNumbers(numbers[index].(*Number), number.(*Number))
+		result = result && ok && compareFunc(ordering)
+	}
+	return NewBoolean(result)
+}
+
+// Compare real numbers in order, such as (< 1 2 3).
+func (s *Subroutine) compareReals(arguments Object, compareFunc func(int) bool) Object {
+	if arguments.isList() {
+		assertRealNumbers(arguments.(*Pair).Elements())
+	}
+	return s.compareNumbers(arguments, compareFunc)
+}
+
+// Fold numbers by operation from the left. If there is only one number,
+// it is folded with identity instead, e.g. (- 1) is (- 0 1).
+func (s *Subroutine) foldNumbers(arguments Object, operation *numberOperation, identity int) Object {
+	assertListMinimum(arguments, 0)
+
+	numbers := arguments.(*Pair).Elements()
 	assertObjectsType(numbers, "number")
 
-	oldValue := numbers[0].(*Number).value
+	if len(numbers) < 2 {
+		numbers = append([]Object{NewNumber(identity)}, numbers...)
+	}
+	result := numbers[0].(*Number)
 	for _, number := range numbers[1:] {
-		if !compareFunc(oldValue, number.(*Number).value) {
-			return NewBoolean(false)
+		result = operation.apply(result, number.(*Number))
+	}
+	return result
+}
+
+// Apply function to a number, which is the only argument.
+func (s *Subroutine) numberByFunc(arguments Object, function func(*Number) Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "number")
+	return function(object.(*Number))
+}
+
+// Apply function to a real number, which is the only argument.
+func (s *Subroutine) realByFunc(arguments Object, function func(*Number) Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertRealNumbers([]Object{object})
+	return function(object.(*Number))
+}
+
+// Divide two integers and apply function to the quotient and the remainder.
+func (s *Subroutine) divideByFunc(arguments Object, floor bool, function func(*Number, *Number) Object) Object {
+	assertListEqual(arguments, 2)
+
+	numbers := arguments.(*Pair).Elements()
+	assertObjectsType(numbers, "number")
+	return function(divideIntegers(numbers[0].(*Number), numbers[1].(*Number), floor))
+}
+
+// Select a real number which is ordered first by compareFunc, such as min and max.
+// The result is inexact if any number is inexact, and NaN is always selected.
+func (s *Subroutine) selectNumber(arguments Object, compareFunc func(int) bool) Object {
+	assertListMinimum(arguments, 1)
+
+	numbers := arguments.(*Pair).Elements()
+	assertRealNumbers(numbers)
+
+	result, exact := numbers[0].(*Number), true
+	for _, object := range numbers {
+		number := object.(*Number)
+		if ordering, ok := compareNumbers(number, result); (ok && compareFunc(ordering)) || math.IsNaN(toFloat(number.value)) {
+			result = number
+		}
+		exact = exact && number.isExact()
+	}
+
+	if !exact {
+		return result.inexact()
+	}
+	return result
+}
+
+// Apply function to a character, which is the only argument.
+func (s *Subroutine) charByFunc(arguments Object, function func(rune) Object) Object {
+	assertListEqual(arguments, 1)
+
+	object := arguments.(*Pair).ElementAt(0)
+	assertObjectType(object, "char")
+	return function(object.(*Char).value)
+}
+
+// Compare each adjacent pair of characters by compareFunc, which receives
+// the difference of their code points. If foldCase is true, characters are
+// compared after case folding.
+func (s *Subroutine) compareChars(arguments Object, foldCase bool, compareFunc func(int) bool) Object {
+	assertListMinimum(arguments, 2)
+
+	chars := arguments.(*Pair).Elements()
+	assertObjectsType(chars, "char")
+
+	result := true
+	for index, char := range chars[1:] {
+		a, b := chars[index].(*Char).value, char.(*Char).value
+		if foldCase {
+			a, b = unicode.ToLower(a), unicode.ToLower(b)
+		}
+		result = result && compareFunc(int(a-b))
+	}
+	return NewBoolean(result)
+}
+
+// Compare each adjacent pair of strings by compareFunc, which receives the
+// result of strings.Compare. If foldCase is true, strings are compared after
+// case folding.
+func (s *Subroutine) compareStrings(arguments Object, foldCase bool, compareFunc func(int) bool) Object {
+	assertListMinimum(arguments, 2)
+
+	texts := arguments.(*Pair).Elements()
+	assertObjectsType(texts, "string")
+
+	result := true
+	for index, text := range texts[1:] {
+		a, b := texts[index].(*String).text(), text.(*String).text()
+		if foldCase {
+			a, b = strings.ToLower(a), strings.ToLower(b)
+		}
+		result = result && compareFunc(strings.Compare(a, b))
+	}
+	return NewBoolean(result)
+}
+
+// Call procedure with each list of arguments in order, and returns the result
+// of then, which receives their values. Since each call is an evaluation
+// point, a continuation captured by procedure can be re-entered.
+func (s *Subroutine) applyEach(procedure Object, argumentLists [][]Object, environment *Environment, then func([]Object, *Environment) Object) Object {
+	codes := make([]Code, len(argumentLists))
+	for index, arguments := range argumentLists {
+		arguments := arguments
+		codes[index] = func(environment *Environment) Object {
+			return applyProcedure(procedure, arguments, environment)
 		}
-		oldValue = number.(*Number).value
 	}
-	return NewBoolean(true)
+	return evalCodesThen(codes, environment, then)
 }
@@ -20,10 +20,6 @@ func NewSymbol(identifier string) *Symbol {
 	return symbols[identifier]
 }
 
-func (s *Symbol) Eval() Object {
-	return s
-}
-
 func (s *Symbol) String() string {
 	return s.identifier
 }
@@ -1,4 +1,5 @@
 // This file is for statements by syntax form, such as set!
+// Syntax forms are compiled into Code when compiler finds them.
 
 package scheme
 
@@ -8,35 +9,52 @@ import (
 
 var (
 	builtinSyntaxes = Binding{
-		"actor":        NewSyntax(actorSyntax),
-		"and":          NewSyntax(andSyntax),
-		"begin":        NewSyntax(beginSyntax),
-		"cond":         NewSyntax(condSyntax),
-		"define":       NewSyntax(defineSyntax),
-		"define-macro": NewSyntax(defineMacroSyntax),
-		"do":           NewSyntax(doSyntax),
-		"if":           NewSyntax(ifSyntax),
-		"lambda":       NewSyntax(lambdaSyntax),
-		"let":          NewSyntax(letSyntax),
-		"let*":         NewSyntax(letStarSyntax),
-		"letrec":       NewSyntax(letrecSyntax),
-		"or":           NewSyntax(orSyntax),
-		"quote":        NewSyntax(quoteSyntax),
-		"set!":         NewSyntax(setSyntax),
+		"actor":              NewSyntax(actorSyntax),
+		"and":                NewSyntax(andSyntax),
+		"begin":              NewSyntax(beginSyntax),
+		"cond":               NewSyntax(condSyntax),
+		"define":             NewSyntax(defineSyntax),
+		"define-macro":       NewSyntax(defineMacroSyntax),
+		"define-record-type": NewSyntax(defineRecordTypeSyntax),
+		"define-syntax":      NewSyntax(defineSyntaxSyntax),
+		"define-values":      NewSyntax(defineValuesSyntax),
+		"do":                 NewSyntax(doSyntax),
+		"guard":              NewSyntax(guardSyntax),
+		"if":                 NewSyntax(ifSyntax),
+		"lambda":             NewSyntax(lambdaSyntax),
+		"let":                NewSyntax(letSyntax),
+		"let*":               NewSyntax(letStarSyntax),
+		"let*-values":        NewSyntax(letStarValuesSyntax),
+		"let-syntax":         NewSyntax(letSyntaxSyntax),
+		"let-values":         NewSyntax(letValuesSyntax),
+		"letrec":             NewSyntax(letrecSyntax),
+		"letrec*":            NewSyntax(letrecStarSyntax),
+		"letrec-syntax":      NewSyntax(letrecSyntaxSyntax),
+		"or":                 NewSyntax(orSyntax),
+		"quasiquote":         NewSyntax(quasiquoteSyntax),
+		"quote":              NewSyntax(quoteSyntax),
+		"set!":               NewSyntax(setSyntax),
+		"syntax-rules":       NewSyntax(syntaxRulesSyntax),
+		"unless":             NewSyntax(unlessSyntax),
+		"unquote":            NewSyntax(unquoteSyntax),
+		"unquote-splicing":   NewSyntax(unquoteSyntax),
+		"when":               NewSyntax(whenSyntax),
 	}
 )
 
 type Syntax struct {
 	ObjectBase
-	function func(*Syntax, Object) Object
+	function func(*Syntax, *Application, *Scope) Code
 }
 
-func NewSyntax(function func(*Syntax, Object) Object) *Syntax {
+// Syntax's function receives a form of syntax use and the scope where it appears,
+// and returns compiled code of the form.
+func NewSyntax(function func(*Syntax, *Application, *Scope) Code) *Syntax {
 	return &Syntax{ObjectBase: ObjectBase{parent: nil}, function: function}
 }
 
-func (s *Syntax) Invoke(arguments Object) Object {
-	return s.function(s, arguments)
+func (s *Syntax) compile(form *Application, scope *Scope) Code {
+	return s.function(s, form, scope)
 }
 
 func (s *Syntax) String() string {
@@ -47,25 +65,25 @@ func (s *Syntax) isSyntax() bool {
 	return true
 }
 
-func (s *Syntax) malformedError() {
-	syntaxError("malformed %s: %s", s.Bounder(), s.Bounder().Parent())
+func (s *Syntax) malformedError(form *Application) {
+	syntaxError("malformed %s: %s", form.procedure, form)
 }
 
-func (s *Syntax) assertListEqual(arguments Object, length int) {
+func (s *Syntax) assertListEqual(form *Application, arguments Object, length int) {
 	if !arguments.isList() || arguments.(*Pair).ListLength() != length {
-		s.malformedError()
+		s.malformedError(form)
 	}
 }
 
-func (s *Syntax) assertListMinimum(arguments Object, minimum int) {
+func (s *Syntax) assertListMinimum(form *Application, arguments Object, minimum int) {
 	if !arguments.isList() || arguments.(*Pair).ListLength() < minimum {
-		s.malformedError()
+		s.malformedError(form)
 	}
 }
 
-func (s *Syntax) assertListRange(arguments Object, lengthRange []int) {
+func (s *Syntax) assertListRange(form *Application, arguments Object, lengthRange []int) {
 	if !arguments.isList() {
-		s.malformedError()
+		s.malformedError(form)
 	}
 
 	for _, length := range lengthRange {
@@ -73,319 +91,1119 @@ func (s *Syntax) assertListRange(arguments Object, lengthRange []int) {
 			return
 		}
 	}
-	s.malformedError()
+	s.malformedError(form)
 }
 
 // Returns elements in list object with type assertion (syntax form specific error message)
 // Assertion is minimum
-func (s *Syntax) elementsMinimum(list Object, minimum int) []Object {
+func (s *Syntax) elementsMinimum(form *Application, list Object, minimum int) []Object {
 	if list.isApplication() {
 		list = list.(*Application).toList()
 	}
-	s.assertListMinimum(list, minimum)
+	s.assertListMinimum(form, list, minimum)
 	return list.(*Pair).Elements()
 }
 
 // Returns elements in list object with type assertion (syntax form specific error message)
 // Assertion is equal
-func (s *Syntax) elementsExact(list Object, value int) []Object {
+func (s *Syntax) elementsExact(form *Application, list Object, value int) []Object {
 	if list.isApplication() {
 		list = list.(*Application).toList()
 	}
-	s.assertListEqual(list, value)
+	s.assertListEqual(form, list, value)
 	return list.(*Pair).Elements()
 }
 
-// Eval all given objects and returns last object's eval result.
-// When 'objects' is empty, returns #<undef>.
-func evalAll(objects []Object) Object {
-	lastResult := undef
-	for _, object := range objects {
-		lastResult = object.Eval()
+// Returns parameter variables and a rest parameter of lambda list,
+// which is a variable, a list of variables or a dotted list of variables.
+// Rest parameter is nil if lambda list is a proper list.
+func (s *Syntax) parameters(form *Application, list Object) ([]Object, Object) {
+	if list.isApplication() {
+		list = list.(*Application).toList()
 	}
-	return lastResult
+
+	variables := []Object{}
+	for ; list.isPair(); list = list.(*Pair).Cdr {
+		if !list.(*Pair).Car.isVariable() {
+			s.malformedError(form)
+		}
+		variables = append(variables, list.(*Pair).Car)
+	}
+
+	if list.isVariable() {
+		return variables, list
+	} else if !list.isNull() {
+		s.malformedError(form)
+	}
+	return variables, nil
 }
 
-func actorSyntax(s *Syntax, arguments Object) Object {
-	elements := s.elementsMinimum(arguments, 0)
+// Eval transformer specification of let-syntax family at compile time.
+// It can refer only global variables because local frames do not exist yet.
+// Returns variables and values in a list of bindings such as ((variable value) ...).
+func (s *Syntax) bindings(form *Application, list Object) ([]Object, []Object) {
+	variables := []Object{}
+	values := []Object{}
+	for _, argumentElement := range s.elementsMinimum(form, list, 0) {
+		variableElements := s.elementsExact(form, argumentElement, 2)
+		variables = append(variables, variableElements[0])
+		values = append(values, variableElements[1])
+	}
+	return variables, values
+}
+
+// Allocate slots for formals which receive multiple values,
+// and returns a function to bind values to the slots.
+func (s *Syntax) allocateFormals(form *Application, formals Object, scope *Scope) func(*Environment, Object) {
+	variables, rest := s.parameters(form, formals)
+	if rest != nil {
+		variables = append(variables, rest)
+	}
 
-	// Insert over the application to override scope
-	application := arguments.Parent()
-	actor := NewActor(application.Parent())
-	application.setParent(actor)
+	slots := []int{}
+	for _, variable := range variables {
+		slot := scope.allocate(variableName(variable))
+		delete(scope.macros, slot)
+		slots = append(slots, slot)
+	}
+	return func(frame *Environment, object Object) {
+		bindValues(frame, slots, rest != nil, object)
+	}
+}
 
+func (s *Syntax) evalTransformer(object Object, scope *Scope) Object {
+	code := compile(object, scope)
+	environment := newEvaluation(scope.global())
+	return s.assertTransformer(evalRoot(func() Object {
+		return code(environment)
+	}, environment.evaluation, true))
+}
+
+func (s *Syntax) assertTransformer(transformer Object) Object {
+	if _, ok := transformer.(Expander); !ok {
+		syntaxError("transformer required, but got %s", transformer)
+	}
+	return transformer
+}
+
+// Returns true if object is the builtin syntax named name.
+func isSyntaxOf(object Object, name string) bool {
+	return object != nil && object.isSyntax() && identifierName(object.Bounder()) == name
+}
+
+// Every object except #f is regarded as true in conditional expressions.
+func isTrue(object Object) bool {
+	return !object.isBoolean() || object.(*Boolean).value
+}
+
+func actorSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 0)
+
+	// Message arguments and definitions in handlers are bound to actor's frame,
+	// which keeps actor's state between messages.
+	actorScope := NewScope(scope, NewVariable("self", nil))
 	for _, element := range elements {
-		caseElements := s.elementsMinimum(element, 1)
-		caseArguments := s.elementsMinimum(caseElements[0], 1)
+		caseElements := s.elementsMinimum(form, element, 1)
+		caseArguments := s.elementsMinimum(form, caseElements[0], 1)
 		assertObjectType(caseArguments[0], "string")
 
-		actor.functions[caseArguments[0].(*String).text] = func(objects []Object) {
-			if len(caseArguments[1:]) != len(objects) {
-				runtimeError("invalid message argument length: requires %d, but got %d", len(caseArguments[1:]), len(objects))
-			}
+		for _, variable := range caseArguments[1:] {
+			actorScope.allocate(variableName(variable))
+		}
+		scanDefinitions(caseElements[1:], actorScope)
+	}
 
-			for index, variable := range caseArguments[1:] {
-				actor.tryDefine(variable, objects[index].Eval())
-			}
-			evalAll(caseElements[1:])
+	type handler struct {
+		slots []int
+		body  Code
+	}
+	handlers := map[string]*handler{}
+	for _, element := range elements {
+		caseElements := s.elementsMinimum(form, element, 1)
+		caseArguments := s.elementsMinimum(form, caseElements[0], 1)
+
+		h := &handler{body: compileBody(caseElements[1:], actorScope)}
+		for _, variable := range caseArguments[1:] {
+			h.slots = append(h.slots, actorScope.index(variableName(variable)))
 		}
+		handlers[caseArguments[0].(*String).text()] = h
 	}
 
-	return actor
+	return func(environment *Environment) Object {
+		actor := NewActor(environment, actorScope.size())
+
+		for message, h := range handlers {
+			h := h
+			actor.functions[message] = func(objects []Object) {
+				if len(h.slots) != len(objects) {
+					runtimeError("invalid message argument length: requires %d, but got %d", len(h.slots), len(objects))
+				}
+
+				for index, slot := range h.slots {
+					actor.environment.put(slot, objects[index])
+				}
+				evalRoot(func() Object {
+					return compileForced(h.body)(actor.environment)
+				}, actor.environment.evaluation, false)
+			}
+		}
+		return actor
+	}
 }
 
-func andSyntax(s *Syntax, arguments Object) Object {
-	s.assertListMinimum(arguments, 0)
+func andSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	s.assertListMinimum(form, form.arguments, 0)
 
-	lastResult := Object(NewBoolean(true))
-	for _, object := range arguments.(*Pair).Elements() {
-		lastResult = object.Eval()
-		if lastResult.isBoolean() && lastResult.(*Boolean).value == false {
-			return NewBoolean(false)
+	elements := form.arguments.(*Pair).Elements()
+	if len(elements) == 0 {
+		return func(environment *Environment) Object {
+			return NewBoolean(true)
 		}
 	}
-	return lastResult
+	codes := compileAll(elements[:len(elements)-1], scope)
+	code := compileTail(elements[len(elements)-1], scope)
+
+	// chain codes from the last one, each of which evaluates the next one if its value is true
+	for index := len(codes) - 1; index >= 0; index-- {
+		next := code
+		code = compileThen(codes[index], func(result Object, environment *Environment) Object {
+			if !isTrue(result) {
+				return NewBoolean(false)
+			}
+			return next(environment)
+		})
+	}
+	return code
 }
 
-func beginSyntax(s *Syntax, arguments Object) Object {
-	elements := s.elementsMinimum(arguments, 0)
-	return evalAll(elements)
+func beginSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 0)
+	return compileBody(elements, scope)
 }
 
-func condSyntax(s *Syntax, arguments Object) Object {
-	elements := s.elementsMinimum(arguments, 0)
+func condSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 0)
 	if len(elements) == 0 {
 		syntaxError("at least one clause is required for cond")
 	}
 
+	return compileClauses("cond", elements, scope, func(environment *Environment) Object {
+		return undef
+	}, (*clause).eval)
+}
+
+// Clause of cond and guard
+type clause struct {
+	test     Code // nil for else clause
+	body     Code // nil for clause which has only test
+	receiver Code // procedure of (test => receiver), or nil
+}
+
+// Evaluate clause whose test returns value.
+func (c *clause) eval(value Object, environment *Environment) Object {
+	if c.receiver != nil {
+		return evalThen(c.receiver, environment, func(receiver Object, environment *Environment) Object {
+			return applyTail(receiver, []Object{value}, environment)
+		})
+	} else if c.body == nil {
+		return value
+	}
+	return c.body(environment)
+}
+
+// Compile clauses of cond and guard, which evaluates otherwise if all tests are #f.
+// A clause whose test is not #f is evaluated by selected with the value of test.
+func compileClauses(keyword string, elements []Object, scope *Scope, otherwise Code, selected func(*clause, Object, *Environment) Object) Code {
 	// First: syntax check
 	elseExists := false
 	for _, element := range elements {
 		if elseExists {
 			syntaxError("'else' clause followed by more clauses")
-		} else if element.isApplication() && element.(*Application).procedure.isVariable() &&
-			element.(*Application).procedure.(*Variable).identifier == "else" {
+		} else if element.isApplication() && identifierName(element.(*Application).procedure) == "else" {
 			elseExists = true
 		}
 
 		if element.isNull() || !element.isApplication() {
-			syntaxError("bad clause in cond")
+			syntaxError("bad clause in %s", keyword)
 		}
 	}
 
-	// Second: eval cases
+	// Second: compile clauses
+	clauses := []*clause{}
 	for _, element := range elements {
-		lastResult := undef
 		application := element.(*Application)
 
-		isElse := application.procedure.isVariable() && application.procedure.(*Variable).identifier == "else"
-		if !isElse {
-			lastResult = application.procedure.Eval()
+		c := &clause{}
+		if identifierName(application.procedure) != "else" {
+			c.test = compile(application.procedure, scope)
+		}
+		body := application.arguments.(*Pair).Elements()
+		if len(body) > 0 && identifierName(body[0]) == "=>" {
+			if len(body) != 2 || c.test == nil {
+				syntaxError("bad clause in %s", keyword)
+			}
+			c.receiver = compile(body[1], scope)
+		} else if len(body) > 0 {
+			c.body = compileBody(body, scope)
 		}
+		clauses = append(clauses, c)
+	}
 
-		// first element is 'else' or not '#f'
-		if isElse || !lastResult.isBoolean() || lastResult.(*Boolean).value == true {
-			for _, object := range application.arguments.(*Pair).Elements() {
-				lastResult = object.Eval()
+	// chain clauses from the last one, each of which evaluates the next one if its test is #f
+	code := otherwise
+	for index := len(clauses) - 1; index >= 0; index-- {
+		c, next := clauses[index], code
+		if c.test == nil {
+			if c.body != nil {
+				code = func(environment *Environment) Object {
+					return selected(c, undef, environment)
+				}
 			}
-			return lastResult
+			continue
 		}
+
+		code = compileThen(c.test, func(lastResult Object, environment *Environment) Object {
+			if !isTrue(lastResult) {
+				return next(environment)
+			}
+			return selected(c, lastResult, environment)
+		})
 	}
-	return undef
+	return code
 }
 
-func defineSyntax(s *Syntax, arguments Object) Object {
-	elements := s.elementsExact(arguments, 2)
+func defineSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 2)
 
 	if elements[0].isVariable() {
-		variable := elements[0].(*Variable)
-		s.Bounder().define(variable.identifier, elements[1].Eval())
-
-		return NewSymbol(variable.identifier)
+		s.assertListEqual(form, form.arguments, 2)
+		return compileDefinition(elements[0].(*Variable), scope, compile(elements[1], scope))
 	} else if elements[0].isApplication() {
-		closure := WrapClosure(arguments)
-
-		defineElements := s.elementsMinimum(elements[0], 1)
-		funcName := defineElements[0]
-		closure.DefineFunction(s, defineElements[1:], elements[1:])
+		funcName := elements[0].(*Application).procedure
+		variables, rest := s.parameters(form, elements[0].(*Application).arguments)
 
 		if funcName.isVariable() {
-			s.Bounder().define(funcName.(*Variable).identifier, closure)
-			return funcName
+			variable := funcName.(*Variable)
+			if !scope.isGlobal() {
+				// the function can refer itself
+				scope.allocate(variable.identifier)
+			}
+			return compileDefinition(variable, scope, compileLambda(variables, rest, elements[1:], scope))
+		}
+	}
+	syntaxError("%s", form)
+	return nil
+}
+
+func defineMacroSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 2)
+
+	var variable *Variable
+	var transformerCode Code
+	if elements[0].isVariable() {
+		s.assertListEqual(form, form.arguments, 2)
+		variable = elements[0].(*Variable)
+		transformerCode = compile(elements[1], scope)
+	} else {
+		if !elements[0].isApplication() {
+			s.malformedError(form)
 		}
+		assertObjectType(elements[0].(*Application).procedure, "variable")
+		variable = elements[0].(*Application).procedure.(*Variable)
+		variables, rest := s.parameters(form, elements[0].(*Application).arguments)
+		transformerCode = compileLambda(variables, rest, elements[1:], scope)
 	}
-	return syntaxError("%s", s.Bounder().Parent())
+
+	definition := compileDefinition(variable, scope, compileThen(transformerCode, func(transformer Object, environment *Environment) Object {
+		assertObjectType(transformer, "closure")
+		return NewMacro(transformer.(*Closure))
+	}))
+	return compileThen(definition, func(Object, *Environment) Object {
+		return undef
+	})
 }
 
-func defineMacroSyntax(s *Syntax, arguments Object) Object {
-	elements := s.elementsMinimum(arguments, 2)
+// Define a record type, its constructor, predicate, accessors and modifiers, such as
+// (define-record-type <point> (make-point x y) point? (x point-x set-point-x!) (y point-y)).
+// A constructor given as an identifier initializes all fields in order.
+func defineRecordTypeSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 3)
+	if !elements[0].isVariable() {
+		s.malformedError(form)
+	}
 
-	macroElements := s.elementsMinimum(elements[0], 1)
-	assertObjectType(macroElements[0], "variable")
+	fields, known := []string{}, map[string]bool{}
+	for _, spec := range elements[3:] {
+		specElements := s.elementsMinimum(form, spec, 1)
+		if len(specElements) > 3 {
+			s.malformedError(form)
+		}
+		for _, element := range specElements {
+			if !element.isVariable() {
+				s.malformedError(form)
+			}
+		}
+		fields = append(fields, variableName(specElements[0]))
+		known[variableName(specElements[0])] = true
+	}
+
+	// the type, constructor and predicate are followed by accessors and modifiers
+	variables := []Object{elements[0]}
+	makers := []func(*RecordType) Object{func(recordType *RecordType) Object {
+		return recordType
+	}}
+	if elements[1].isVariable() {
+		variables = append(variables, elements[1])
+		makers = append(makers, func(recordType *RecordType) Object {
+			return recordType.constructor(recordType.fields)
+		})
+	} else if elements[1].isApplication() {
+		constructorElements := s.elementsMinimum(form, elements[1], 1)
+		constructorFields := []string{}
+		for _, element := range constructorElements {
+			if !element.isVariable() {
+				s.malformedError(form)
+			}
+			constructorFields = append(constructorFields, variableName(element))
+		}
+		for _, field := range constructorFields[1:] {
+			if !known[field] {
+				syntaxError("unknown field %s in %s", field, form)
+			}
+		}
+		variables = append(variables, constructorElements[0])
+		makers = append(makers, func(recordType *RecordType) Object {
+			return recordType.constructor(constructorFields[1:])
+		})
+	} else if isTrue(elements[1]) {
+		s.malformedError(form)
+	}
+
+	if elements[2].isVariable() {
+		variables = append(variables, elements[2])
+		makers = append(makers, func(recordType *RecordType) Object {
+			return recordType.predicate()
+		})
+	} else if isTrue(elements[2]) {
+		s.malformedError(form)
+	}
 
-	macro := NewMacro()
-	s.Bounder().define(macroElements[0].(*Variable).identifier, macro)
-	return undef
+	for _, spec := range elements[3:] {
+		specElements := s.elementsMinimum(form, spec, 1)
+		field := variableName(specElements[0])
+		if len(specElements) > 1 {
+			variables = append(variables, specElements[1])
+			makers = append(makers, func(recordType *RecordType) Object {
+				return recordType.accessor(field)
+			})
+		}
+		if len(specElements) > 2 {
+			variables = append(variables, specElements[2])
+			makers = append(makers, func(recordType *RecordType) Object {
+				return recordType.modifier(field)
+			})
+		}
+	}
+
+	typeName := variableName(elements[0])
+	valueCode := func(environment *Environment) Object {
+		recordType := NewRecordType(typeName, fields)
+		values := []Object{}
+		for index, maker := range makers {
+			values = append(values, nameObject(maker(recordType), variables[index].(*Variable)))
+		}
+		return NewValues(values)
+	}
+	definition := s.compileDefineValues(form, NewList(nil, variables...), valueCode, scope)
+	return compileThen(definition, func(Object, *Environment) Object {
+		return NewSymbol(typeName)
+	})
+}
+
+func defineSyntaxSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsExact(form, form.arguments, 2)
+	if !elements[0].isVariable() {
+		s.malformedError(form)
+	}
+	variable := elements[0].(*Variable)
+
+	var definition Code
+	if scope.isGlobal() {
+		// global transformer is defined at runtime, and found by later compilation
+		transformerCode := compile(elements[1], scope)
+		definition = compileDefinition(variable, scope, compileThen(transformerCode, func(transformer Object, environment *Environment) Object {
+			return s.assertTransformer(transformer)
+		}))
+	} else {
+		// local transformer is bound at compile time to expand the rest of body
+		transformer := nameObject(s.evalTransformer(elements[1], scope), variable)
+		index := scope.defineMacro(variable.identifier, transformer)
+		definition = func(environment *Environment) Object {
+			environment.put(index, transformer)
+			return undef
+		}
+	}
+
+	return compileThen(definition, func(Object, *Environment) Object {
+		return undef
+	})
 }
 
-func doSyntax(s *Syntax, arguments Object) Object {
-	closure := WrapClosure(arguments.Parent())
+func defineValuesSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsExact(form, form.arguments, 2)
+	return s.compileDefineValues(form, elements[0], compile(elements[1], scope), scope)
+}
 
-	// Parse iterator list and define first variable
-	elements := s.elementsMinimum(arguments, 2)
-	iteratorBodies := s.elementsMinimum(elements[0], 0)
+// Returns code which defines variables in formals by values of valueCode.
+func (s *Syntax) compileDefineValues(form *Application, formals Object, valueCode Code, scope *Scope) Code {
+	if !scope.isGlobal() {
+		bind := s.allocateFormals(form, formals, scope)
+		return compileThen(valueCode, func(values Object, environment *Environment) Object {
+			bind(environment, values)
+			return undef
+		})
+	}
+
+	// bind values to a temporary frame, and define them in global environment
+	frameScope := NewScope(scope)
+	bind := s.allocateFormals(form, formals, frameScope)
+	global := scope.global()
+	return compileThen(valueCode, func(values Object, environment *Environment) Object {
+		frame := NewEnvironment(nil, frameScope.size())
+		bind(frame, values)
+		for index, identifier := range frameScope.names {
+			global.define(identifier, frame.get(index))
+		}
+		return undef
+	})
+}
+
+func doSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	// Parse iterator list and compile first values in outer scope
+	elements := s.elementsMinimum(form, form.arguments, 2)
+	iteratorBodies := s.elementsMinimum(form, elements[0], 0)
+
+	variables := []Object{}
+	initCodes := []Code{}
 	for _, iteratorBody := range iteratorBodies {
-		iteratorElements := s.elementsMinimum(iteratorBody, 2)
+		iteratorElements := s.elementsMinimum(form, iteratorBody, 2)
 		if len(iteratorElements) > 3 {
-			compileError("bad update expr in %s: %s", s.Bounder(), s.Bounder().Parent())
+			compileError("bad update expr in %s: %s", form.procedure, form)
+		}
+		variables = append(variables, iteratorElements[0])
+		initCodes = append(initCodes, compile(iteratorElements[1], scope))
+	}
+
+	// Iterator without update keeps its value
+	doScope := NewScope(scope, variables...)
+	updateCodes := []Code{}
+	for index, iteratorBody := range iteratorBodies {
+		iteratorElements := s.elementsMinimum(form, iteratorBody, 2)
+		if len(iteratorElements) == 3 {
+			updateCodes = append(updateCodes, compile(iteratorElements[2], doScope))
+		} else {
+			updateCodes = append(updateCodes, compile(variables[index], doScope))
+		}
+	}
+
+	testElements := s.elementsMinimum(form, elements[1], 1)
+	testCode := compile(testElements[0], doScope)
+	testBodyCode := compileBody(testElements[1:], doScope)
+	continueBodyCode := compileForced(compileBody(elements[2:], doScope))
+
+	finish := func(testResult Object, frame *Environment) Object {
+		if len(testElements) == 1 {
+			return testResult
 		}
-		closure.tryDefine(iteratorElements[0], iteratorElements[1].Eval())
+		return testBodyCode(frame)
 	}
 
 	// eval test ->
 	//   true: eval testBody and returns its result
-	//  false: eval continueBody, eval iterator's update
-	testElements := s.elementsMinimum(elements[1], 1)
-	for {
-		testResult := testElements[0].Eval()
-		if !testResult.isBoolean() || testResult.(*Boolean).value == true {
-			for _, element := range testElements[1:] {
-				testResult = element.Eval()
-			}
-			return testResult
-		} else {
-			// eval continueBody
-			evalAll(elements[2:])
-
-			// update iterators
-			for _, iteratorBody := range iteratorBodies {
-				iteratorElements := s.elementsMinimum(iteratorBody, 2)
-				if len(iteratorElements) == 3 {
-					closure.tryDefine(iteratorElements[0], iteratorElements[2].Eval())
+	//  false: eval continueBody, eval iterator's update in a new frame
+	// Frames of continuations captured in an iteration finish it and continue the loop.
+	return func(environment *Environment) Object {
+		newFrame := func(values []Object) *Environment {
+			frame := NewEnvironment(environment, doScope.size())
+			copy(frame.values, values)
+			return frame
+		}
+
+		var loop func(*Environment) Object
+		update := func(frame *Environment) *Environment {
+			return newFrame(evalCodes(updateCodes, frame, func() resumption {
+				return func(values Object) Object {
+					return loop(newFrame(valuesOf(values)))
+				}
+			}))
+		}
+		iterate := func(frame *Environment) *Environment {
+			evalPoint(continueBodyCode, frame, func() resumption {
+				return func(Object) Object {
+					return loop(update(frame))
+				}
+			})
+			return update(frame)
+		}
+		loop = func(frame *Environment) Object {
+			for {
+				testResult := evalPoint(testCode, frame, func() resumption {
+					return func(testResult Object) Object {
+						if isTrue(testResult) {
+							return finish(testResult, frame)
+						}
+						return loop(iterate(frame))
+					}
+				})
+				if isTrue(testResult) {
+					return finish(testResult, frame)
 				}
+				frame = iterate(frame)
 			}
 		}
+
+		return evalCodesThen(initCodes, environment, func(values []Object, environment *Environment) Object {
+			return loop(newFrame(values))
+		})
 	}
-	return undef
 }
 
-func ifSyntax(s *Syntax, arguments Object) Object {
-	s.assertListRange(arguments, []int{2, 3})
-	elements := arguments.(*Pair).Elements()
+// Guard evaluates body, and if an object is raised in body, binds it to
+// variable and evaluates clauses like cond. If no clause is selected,
+// the object is raised again to outer handlers.
+func guardSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+	specs := s.elementsMinimum(form, elements[0], 1)
+	variable, ok := specs[0].(*Variable)
+	if !ok {
+		syntaxError("variable required for guard, but got %s", specs[0])
+	}
 
-	result := elements[0].Eval()
-	if result.isBoolean() && !result.(*Boolean).value {
-		if len(elements) == 3 {
-			return elements[2].Eval()
-		} else {
+	bodyCode := compileForced(compileBody(elements[1:], scope))
+
+	// clauses are tested in the handler, which returns the satisfied one to escape with
+	guardScope := NewScope(scope, variable)
+	clausesCode := compileClauses("guard", specs[1:], guardScope, func(environment *Environment) Object {
+		return undef
+	}, func(c *clause, value Object, environment *Environment) Object {
+		return &guardSignal{clause: c, value: value, environment: environment}
+	})
+
+	return func(environment *Environment) Object {
+		return newGuard(clausesCode, guardScope.size()).eval(bodyCode, environment)
+	}
+}
+
+func ifSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	s.assertListRange(form, form.arguments, []int{2, 3})
+	elements := form.arguments.(*Pair).Elements()
+
+	testCode := compile(elements[0], scope)
+	thenCode := compileTail(elements[1], scope)
+	if len(elements) == 2 {
+		return compileThen(testCode, func(testResult Object, environment *Environment) Object {
+			if isTrue(testResult) {
+				return thenCode(environment)
+			}
 			return undef
+		})
+	}
+
+	elseCode := compileTail(elements[2], scope)
+	return compileThen(testCode, func(testResult Object, environment *Environment) Object {
+		if isTrue(testResult) {
+			return thenCode(environment)
 		}
-	} else {
-		return elements[1].Eval()
+		return elseCode(environment)
+	})
+}
+
+func lambdaSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+	variables, rest := s.parameters(form, elements[0])
+	return compileLambda(variables, rest, elements[1:], scope)
+}
+
+func letSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+	if elements[0].isVariable() {
+		return namedLetSyntax(s, form, scope)
+	}
+
+	// compile arguments in outer scope
+	variables, values := s.bindings(form, elements[0])
+	initCodes := compileAll(values, scope)
+
+	letScope := NewScope(scope, variables...)
+	bodyCode := compileBody(elements[1:], letScope)
+
+	bind := func(values []Object, environment *Environment) Object {
+		frame := NewEnvironment(environment, letScope.size())
+		copy(frame.values, values)
+		return bodyCode(frame)
+	}
+	return func(environment *Environment) Object {
+		return evalCodesThen(initCodes, environment, bind)
 	}
 }
 
-func lambdaSyntax(s *Syntax, arguments Object) Object {
-	closure := WrapClosure(arguments.Parent())
+// Named let binds a procedure, whose name is visible only in its body,
+// and calls it with initial values.
+func namedLetSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 2)
+	name := elements[0].(*Variable)
+
+	variables, values := s.bindings(form, elements[1])
+	initCodes := compileAll(values, scope)
 
-	elements := s.elementsMinimum(arguments, 1)
-	variables := s.elementsMinimum(elements[0], 0)
-	closure.DefineFunction(s, variables, elements[1:])
-	return closure
+	loopScope := NewScope(scope, name)
+	lambdaCode := compileLambda(variables, nil, elements[2:], loopScope)
+
+	call := func(values []Object, environment *Environment) Object {
+		frame := NewEnvironment(environment, loopScope.size())
+		closure := nameObject(lambdaCode(frame), name).(*Closure)
+		frame.put(0, closure)
+		return NewTailCall(closure, values, environment.evaluation)
+	}
+	return func(environment *Environment) Object {
+		return evalCodesThen(initCodes, environment, call)
+	}
 }
 
-func letSyntax(s *Syntax, arguments Object) Object {
-	closure := WrapClosure(arguments.Parent())
-	elements := s.elementsMinimum(arguments, 1)
+func letStarSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
 
-	// define arguments to local scope
-	variables := []Object{}
-	results := []Object{}
-	for _, argumentElement := range s.elementsMinimum(elements[0], 0) {
-		variableElements := s.elementsExact(argumentElement, 2)
+	// each argument is bound in a new scope, which can refer former ones
+	scopes := []*Scope{}
+	initCodes := []Code{}
+	letScope := scope
+	for _, argumentElement := range s.elementsMinimum(form, elements[0], 0) {
+		variableElements := s.elementsExact(form, argumentElement, 2)
+		initCodes = append(initCodes, compile(variableElements[1], letScope))
 
-		variableElements[1].setParent(closure.Parent())
-		variables = append(variables, variableElements[0])
-		results = append(results, variableElements[1].Eval())
+		letScope = NewScope(letScope, variableElements[0])
+		scopes = append(scopes, letScope)
+	}
+	bodyScope := NewScope(letScope)
+	bodyCode := compileBody(elements[1:], bodyScope)
+
+	// chain bindings from the last one, each of which creates a frame for the next one
+	code := func(frame *Environment) Object {
+		return bodyCode(NewEnvironment(frame, bodyScope.size()))
+	}
+	for index := len(initCodes) - 1; index >= 0; index-- {
+		next, size := code, scopes[index].size()
+		code = compileThen(initCodes[index], func(result Object, frame *Environment) Object {
+			frame = NewEnvironment(frame, size)
+			frame.put(0, result)
+			return next(frame)
+		})
 	}
+	return code
+}
 
-	for index, variable := range variables {
-		closure.tryDefine(variable, results[index])
+func letStarValuesSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+	formals, values := s.bindings(form, elements[0])
+
+	// each formals are bound in a new scope, which can refer former ones
+	scopes := []*Scope{}
+	binds := []func(*Environment, Object){}
+	initCodes := []Code{}
+	letScope := scope
+	for index, formal := range formals {
+		initCodes = append(initCodes, compile(values[index], letScope))
+
+		letScope = NewScope(letScope)
+		binds = append(binds, s.allocateFormals(form, formal, letScope))
+		scopes = append(scopes, letScope)
 	}
+	bodyScope := NewScope(letScope)
+	bodyCode := compileBody(elements[1:], bodyScope)
 
-	// eval body
-	return evalAll(elements[1:])
+	// chain bindings from the last one, each of which creates a frame for the next one
+	code := func(frame *Environment) Object {
+		return bodyCode(NewEnvironment(frame, bodyScope.size()))
+	}
+	for index := len(initCodes) - 1; index >= 0; index-- {
+		next, size, bind := code, scopes[index].size(), binds[index]
+		code = compileThen(initCodes[index], func(result Object, frame *Environment) Object {
+			frame = NewEnvironment(frame, size)
+			bind(frame, result)
+			return next(frame)
+		})
+	}
+	return code
 }
 
-func letStarSyntax(s *Syntax, arguments Object) Object {
-	closure := WrapClosure(arguments.Parent())
-	elements := s.elementsMinimum(arguments, 1)
+func letSyntaxSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+	letScope := NewScope(scope)
+
+	// define transformers to local scope, which are evaluated in outer scope
+	for _, argumentElement := range s.elementsMinimum(form, elements[0], 0) {
+		variableElements := s.elementsExact(form, argumentElement, 2)
+		transformer := s.evalTransformer(variableElements[1], scope)
+		letScope.defineMacro(variableName(variableElements[0]), nameTransformer(transformer, variableElements[0]))
+	}
+
+	return compileSyntaxBody(elements[1:], letScope)
+}
 
-	// define arguments to local scope
-	for _, argumentElement := range s.elementsMinimum(elements[0], 0) {
-		variableElements := s.elementsExact(argumentElement, 2)
-		variable, result := variableElements[0], variableElements[1].Eval()
+func letrecSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	return compileLetrec(s, form, scope, false)
+}
 
-		closure.tryDefine(variable, result)
-		result.setParent(closure.Parent())
+func letrecStarSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	return compileLetrec(s, form, scope, true)
+}
+
+// Compile letrec or letrec*, whose values are evaluated in the scope of variables.
+// If sequential is true, each variable is bound before evaluating the next value.
+func compileLetrec(s *Syntax, form *Application, scope *Scope, sequential bool) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+	variables, values := s.bindings(form, elements[0])
+
+	letScope := NewScope(scope, variables...)
+	initCodes := compileAll(values, letScope)
+	bodyCode := compileBody(elements[1:], letScope)
+
+	bind := func(frame *Environment, index int, result Object) {
+		if variables[index].isVariable() {
+			nameObject(result, variables[index].(*Variable))
+		}
+		frame.put(index, result)
+	}
+
+	var code Code
+	if sequential {
+		// chain bindings from the last one, each of which is bound before the next one
+		code = bodyCode
+		for index := len(initCodes) - 1; index >= 0; index-- {
+			index, next := index, code
+			code = compileThen(initCodes[index], func(result Object, frame *Environment) Object {
+				bind(frame, index, result)
+				return next(frame)
+			})
+		}
+	} else {
+		code = func(frame *Environment) Object {
+			return evalCodesThen(initCodes, frame, func(results []Object, frame *Environment) Object {
+				for index, result := range results {
+					bind(frame, index, result)
+				}
+				return bodyCode(frame)
+			})
+		}
 	}
 
-	// eval body
-	return evalAll(elements[1:])
+	return func(environment *Environment) Object {
+		return code(NewEnvironment(environment, letScope.size()))
+	}
 }
 
-func letrecSyntax(s *Syntax, arguments Object) Object {
-	closure := WrapClosure(arguments.Parent())
-	elements := s.elementsMinimum(arguments, 1)
+func letrecSyntaxSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+	letScope := NewScope(scope)
 
-	// define arguments to local scope
-	variables := []Object{}
-	results := []Object{}
-	for _, argumentElement := range s.elementsMinimum(elements[0], 0) {
-		variableElements := s.elementsExact(argumentElement, 2)
-		variables = append(variables, variableElements[0])
+	// define transformers to local scope, which can refer each other
+	argumentElements := s.elementsMinimum(form, elements[0], 0)
+	for _, argumentElement := range argumentElements {
+		variableElements := s.elementsExact(form, argumentElement, 2)
+		letScope.allocate(variableName(variableElements[0]))
+	}
+	for _, argumentElement := range argumentElements {
+		variableElements := s.elementsExact(form, argumentElement, 2)
+		transformer := s.evalTransformer(variableElements[1], letScope)
+		letScope.defineMacro(variableName(variableElements[0]), nameTransformer(transformer, variableElements[0]))
+	}
 
-		results = append(results, variableElements[1].Eval())
+	return compileSyntaxBody(elements[1:], letScope)
+}
+
+func letValuesSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+	formals, values := s.bindings(form, elements[0])
+
+	// compile values in outer scope
+	initCodes := compileAll(values, scope)
+	letScope := NewScope(scope)
+	binds := []func(*Environment, Object){}
+	for _, formal := range formals {
+		binds = append(binds, s.allocateFormals(form, formal, letScope))
+	}
+	bodyCode := compileBody(elements[1:], letScope)
+
+	bind := func(results []Object, environment *Environment) Object {
+		frame := NewEnvironment(environment, letScope.size())
+		for index, result := range results {
+			binds[index](frame, result)
+		}
+		return bodyCode(frame)
+	}
+	return func(environment *Environment) Object {
+		return evalCodesThen(initCodes, environment, bind)
+	}
+}
+
+// Compile body of let-syntax family, whose frame holds transformers.
+func compileSyntaxBody(body []Object, scope *Scope) Code {
+	transformers := make([]Object, scope.size())
+	for index, transformer := range scope.macros {
+		transformers[index] = transformer
 	}
+	bodyCode := compileBody(body, scope)
 
-	for index, variable := range variables {
-		closure.tryDefine(variable, results[index])
+	return func(environment *Environment) Object {
+		frame := NewEnvironment(environment, scope.size())
+		copy(frame.values, transformers)
+		return bodyCode(frame)
 	}
+}
 
-	// eval body
-	return evalAll(elements[1:])
+func nameTransformer(transformer Object, variable Object) Object {
+	if variable.isVariable() {
+		return nameObject(transformer, variable.(*Variable))
+	}
+	return transformer
 }
 
-func orSyntax(s *Syntax, arguments Object) Object {
-	s.assertListMinimum(arguments, 0)
+func orSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	s.assertListMinimum(form, form.arguments, 0)
 
-	lastResult := Object(NewBoolean(false))
-	for _, object := range arguments.(*Pair).Elements() {
-		lastResult = object.Eval()
-		if !lastResult.isBoolean() || lastResult.(*Boolean).value != false {
-			return lastResult
+	elements := form.arguments.(*Pair).Elements()
+	if len(elements) == 0 {
+		return func(environment *Environment) Object {
+			return NewBoolean(false)
 		}
 	}
-	return lastResult
+	codes := compileAll(elements[:len(elements)-1], scope)
+	code := compileTail(elements[len(elements)-1], scope)
+
+	// chain codes from the last one, each of which evaluates the next one if its value is #f
+	for index := len(codes) - 1; index >= 0; index-- {
+		next := code
+		code = compileThen(codes[index], func(lastResult Object, environment *Environment) Object {
+			if isTrue(lastResult) {
+				return lastResult
+			}
+			return next(environment)
+		})
+	}
+	return code
 }
 
-func quoteSyntax(s *Syntax, arguments Object) Object {
-	s.assertListEqual(arguments, 1)
-	object := arguments.(*Pair).ElementAt(0)
+func quasiquoteSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	s.assertListEqual(form, form.arguments, 1)
+	return quasiquote(form.arguments.(*Pair).ElementAt(0), 1, scope)
+}
+
+func quoteSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	s.assertListEqual(form, form.arguments, 1)
 
-	p := NewParser(object.String())
-	p.Peek()
-	return p.parseQuotedObject(s.Bounder())
+	datum := unwrapAliases(form.arguments.(*Pair).ElementAt(0))
+	return func(environment *Environment) Object {
+		return datum
+	}
 }
 
-func setSyntax(s *Syntax, arguments Object) Object {
-	elements := s.elementsExact(arguments, 2)
+func setSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsExact(form, form.arguments, 2)
 
 	variable := elements[0]
 	if !variable.isVariable() {
-		s.malformedError()
+		s.malformedError(form)
+	}
+	return compileAssignment(variable.(*Variable), scope, compile(elements[1], scope))
+}
+
+func syntaxRulesSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	rules := NewSyntaxRules(scope)
+	elements := s.elementsMinimum(form, toDatumList(form.arguments), 1)
+
+	// custom ellipsis: (syntax-rules ellipsis (literal ...) rule ...)
+	if isIdentifier(elements[0]) {
+		if len(elements) < 2 {
+			s.malformedError(form)
+		}
+		rules.ellipsis = elements[0]
+		elements = elements[1:]
+	}
+
+	for _, literal := range s.elementsMinimum(form, elements[0], 0) {
+		if !isIdentifier(literal) {
+			s.malformedError(form)
+		}
+		rules.literals = append(rules.literals, literal)
+	}
+
+	for _, ruleElement := range elements[1:] {
+		rule := s.elementsExact(form, ruleElement, 2)
+		if !rule[0].isPair() {
+			s.malformedError(form)
+		}
+		rules.rules = append(rules.rules, &syntaxRule{pattern: rule[0], template: rule[1]})
+	}
+
+	return func(environment *Environment) Object {
+		return rules
+	}
+}
+
+func unlessSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+
+	testCode := compile(elements[0], scope)
+	bodyCode := compileBody(elements[1:], scope)
+	return compileThen(testCode, func(testResult Object, environment *Environment) Object {
+		if !isTrue(testResult) {
+			return bodyCode(environment)
+		}
+		return undef
+	})
+}
+
+func unquoteSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	syntaxError("%s appeared outside quasiquote: %s", form.procedure, form)
+	return nil
+}
+
+func whenSyntax(s *Syntax, form *Application, scope *Scope) Code {
+	elements := s.elementsMinimum(form, form.arguments, 1)
+
+	testCode := compile(elements[0], scope)
+	bodyCode := compileBody(elements[1:], scope)
+	return compileThen(testCode, func(testResult Object, environment *Environment) Object {
+		if !isTrue(testResult) {
+			return undef
+		}
+		return bodyCode(environment)
+	})
+}
+
+// Compile quasiquote template in nesting level.
+// Unquoted expressions in level 1 are evaluated at runtime.
+func quasiquote(template Object, level int, scope *Scope) Code {
+	if template.isVector() && len(template.(*Vector).elements) > 0 {
+		// vector template is built from the template list of its elements
+		list := toExpression(NewList(nil, template.(*Vector).elements...), template.Parent())
+		return compileThen(quasiquote(list, level, scope), func(object Object, environment *Environment) Object {
+			return NewVector(object.(*Pair).Elements())
+		})
+	}
+	if template.isPair() && !template.isNull() {
+		// list quoted by 'datum, whose unquotes are evaluated
+		list := toExpression(template, template.Parent())
+		return quasiquoteList(NewSymbol("quote"), quasiquote(list, level, scope))
+	}
+	if !template.isApplication() {
+		datum := unwrapAliases(toDatum(template))
+		return func(environment *Environment) Object {
+			return datum
+		}
+	}
+	application := template.(*Application)
+
+	switch identifierName(application.procedure) {
+	case "quote":
+		if application.arguments.isPair() && application.arguments.(*Pair).ListLength() == 1 {
+			argument := toExpression(application.arguments.(*Pair).Car, application)
+			return quasiquoteList(NewSymbol("quote"), quasiquote(argument, level, scope))
+		}
+	case "unquote":
+		if application.arguments.isPair() && application.arguments.(*Pair).ListLength() == 1 {
+			argument := application.arguments.(*Pair).Car
+			if level == 1 {
+				return compile(argument, scope)
+			}
+			return quasiquoteList(NewSymbol("unquote"), quasiquote(argument, level-1, scope))
+		}
+	case "unquote-splicing":
+		if application.arguments.isPair() && application.arguments.(*Pair).ListLength() == 1 && level > 1 {
+			argument := application.arguments.(*Pair).Car
+			return quasiquoteList(NewSymbol("unquote-splicing"), quasiquote(argument, level-1, scope))
+		} else if level == 1 {
+			syntaxError("unquote-splicing appeared outside list: %s", application)
+		}
+	case "quasiquote":
+		if application.arguments.isPair() && application.arguments.(*Pair).ListLength() == 1 {
+			argument := application.arguments.(*Pair).Car
+			return quasiquoteList(NewSymbol("quasiquote"), quasiquote(argument, level+1, scope))
+		}
+	}
+
+	// Template list is application.procedure followed by application.arguments
+	type element struct {
+		code     Code
+		splicing bool
+	}
+	elements := []*element{}
+	var tail Object = &Pair{Car: application.procedure, Cdr: application.arguments}
+	for tail.isPair() {
+		pair := tail.(*Pair)
+		if identifierName(pair.Car) == "unquote" && pair.Cdr.isPair() && pair.Cdr.(*Pair).ListLength() == 1 {
+			// (a unquote b) is (a . ,b)
+			break
+		}
+
+		if pair.Car.isApplication() && identifierName(pair.Car.(*Application).procedure) == "unquote-splicing" && level == 1 {
+			splicing := pair.Car.(*Application)
+			if !splicing.arguments.isPair() || splicing.arguments.(*Pair).ListLength() != 1 {
+				syntaxError("malformed unquote-splicing: %s", splicing)
+			}
+			elements = append(elements, &element{code: compile(splicing.arguments.(*Pair).Car, scope), splicing: true})
+		} else {
+			elements = append(elements, &element{code: quasiquote(pair.Car, level, scope)})
+		}
+		tail = pair.Cdr
 	}
-	value := elements[1].Eval()
-	s.Bounder().set(variable.(*Variable).identifier, value)
-	return value
+
+	var tailCode Code
+	if tail.isPair() {
+		// unquote in tail position
+		tailCode = quasiquote(&Application{procedure: tail.(*Pair).Car, arguments: tail.(*Pair).Cdr}, level, scope)
+	} else {
+		tailCode = quasiquote(tail, level, scope)
+	}
+
+	// evaluate elements followed by the tail, and build a list of them
+	codes := []Code{}
+	for _, e := range elements {
+		codes = append(codes, e.code)
+	}
+	codes = append(codes, tailCode)
+
+	build := func(results []Object, environment *Environment) Object {
+		objects := []Object{}
+		for index, e := range elements {
+			if !e.splicing {
+				objects = append(objects, results[index])
+				continue
+			}
+
+			list := results[index]
+			if !list.isList() {
+				raiseErrorIn(environment, errorKindRuntime, "proper list required for unquote-splicing, but got %s", list)
+			}
+			if !list.isNull() {
+				objects = append(objects, list.(*Pair).Elements()...)
+			}
+		}
+
+		list := results[len(elements)]
+		for i := len(objects) - 1; i >= 0; i-- {
+			list = &Pair{Car: objects[i], Cdr: list}
+		}
+		return list
+	}
+	return func(environment *Environment) Object {
+		return evalCodesThen(codes, environment, build)
+	}
+}
+
+// Returns code which makes a list of keyword and the result of code.
+func quasiquoteList(keyword Object, code Code) Code {
+	return compileThen(code, func(object Object, environment *Environment) Object {
+		return NewList(nil, keyword, object)
+	})
 }
@@ -0,0 +1,253 @@
+// SyntaxRules is a transformer which is generated by syntax-rules.
+// It matches a macro use with its patterns and expands the template
+// of the first matched rule. Symbols inserted by templates are renamed
+// to aliases to keep hygiene.
+
+package scheme
+
+import (
+	"fmt"
+)
+
+type SyntaxRules struct {
+	ObjectBase
+	ellipsis Object
+	literals []Object
+	rules    []*syntaxRule
+	scope    *Scope // scope where syntax-rules is compiled
+}
+
+type syntaxRule struct {
+	pattern  Object
+	template Object
+}
+
+// Bound value of pattern variable.
+// A variable which is followed by ellipsis has a sequence of bindings.
+type patternBinding struct {
+	datum    Object
+	sequence []*patternBinding
+}
+
+type patternBindings map[Object]*patternBinding
+
+func NewSyntaxRules(scope *Scope) *SyntaxRules {
+	return &SyntaxRules{ObjectBase: ObjectBase{parent: nil}, ellipsis: NewSymbol("..."), scope: scope}
+}
+
+func (r *SyntaxRules) String() string {
+	if r.Bounder() == nil {
+		return "#<syntax-rules #f>"
+	}
+	return fmt.Sprintf("#<syntax-rules %s>", r.Bounder())
+}
+
+// Expand given arguments, which must be a list of data, by the first matched rule.
+// Literals in patterns match identifiers which have the same binding in scope of macro use.
+func (r *SyntaxRules) Expand(arguments Object, scope *Scope) Object {
+	for _, rule := range r.rules {
+		bindings := make(patternBindings)
+		if r.match(rule.pattern.(*Pair).Cdr, arguments, bindings, scope) {
+			return r.expand(rule.template, bindings, make(map[Object]*Alias), true)
+		}
+	}
+	return syntaxError("no matching syntax rule: %s", &Pair{Car: r.keyword(), Cdr: unwrapAliases(arguments)})
+}
+
+func (r *SyntaxRules) keyword() Object {
+	if r.Bounder() == nil {
+		return NewSymbol("#f")
+	}
+	return NewSymbol(r.Bounder().String())
+}
+
+func (r *SyntaxRules) isEllipsis(object Object) bool {
+	return isIdentifier(object) && identifierName(object) == identifierName(r.ellipsis)
+}
+
+func (r *SyntaxRules) isLiteral(object Object) bool {
+	for _, literal := range r.literals {
+		if identifierName(literal) == identifierName(object) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if form matches pattern, and stores matched pattern variables to bindings.
+func (r *SyntaxRules) match(pattern Object, form Object, bindings patternBindings, scope *Scope) bool {
+	switch {
+	case isIdentifier(pattern):
+		if r.isLiteral(pattern) {
+			return isIdentifier(form) && r.scope.sameBinding(pattern, scope, form)
+		} else if identifierName(pattern) != "_" {
+			bindings[pattern] = &patternBinding{datum: form}
+		}
+		return true
+	case pattern.isNull():
+		return form.isNull()
+	case pattern.isPair():
+		pair := pattern.(*Pair)
+		if pair.Cdr.isPair() && r.isEllipsis(pair.Cdr.(*Pair).Car) {
+			return r.matchEllipsis(pair.Car, pair.Cdr.(*Pair).Cdr, form, bindings, scope)
+		}
+		if !form.isPair() {
+			return false
+		}
+		return r.match(pair.Car, form.(*Pair).Car, bindings, scope) && r.match(pair.Cdr, form.(*Pair).Cdr, bindings, scope)
+	case pattern.isVector():
+		if !form.isVector() {
+			return false
+		}
+		return r.match(NewList(nil, pattern.(*Vector).elements...), NewList(nil, form.(*Vector).elements...), bindings, scope)
+	default:
+		return areEqual(pattern, form)
+	}
+}
+
+// Match form with "pattern ... tail" pattern.
+// Repeated pattern consumes elements of form except ones for tail.
+func (r *SyntaxRules) matchEllipsis(pattern Object, tail Object, form Object, bindings patternBindings, scope *Scope) bool {
+	formLength, tailLength := 0, 0
+	for object := form; object.isPair(); object = object.(*Pair).Cdr {
+		formLength++
+	}
+	for object := tail; object.isPair(); object = object.(*Pair).Cdr {
+		tailLength++
+	}
+	if formLength < tailLength {
+		return false
+	}
+
+	sequences := make(map[Object][]*patternBinding)
+	for _, variable := range r.patternVariables(pattern) {
+		sequences[variable] = []*patternBinding{}
+	}
+	for i := 0; i < formLength-tailLength; i++ {
+		matched := make(patternBindings)
+		if !r.match(pattern, form.(*Pair).Car, matched, scope) {
+			return false
+		}
+		for variable, binding := range matched {
+			sequences[variable] = append(sequences[variable], binding)
+		}
+		form = form.(*Pair).Cdr
+	}
+
+	for variable, sequence := range sequences {
+		bindings[variable] = &patternBinding{sequence: sequence}
+	}
+	return r.match(tail, form, bindings, scope)
+}
+
+func (r *SyntaxRules) patternVariables(pattern Object) []Object {
+	switch {
+	case isIdentifier(pattern):
+		if r.isLiteral(pattern) || r.isEllipsis(pattern) || identifierName(pattern) == "_" {
+			return []Object{}
+		}
+		return []Object{pattern}
+	case pattern.isPair():
+		return append(r.patternVariables(pattern.(*Pair).Car), r.patternVariables(pattern.(*Pair).Cdr)...)
+	case pattern.isVector():
+		return r.patternVariables(NewList(nil, pattern.(*Vector).elements...))
+	default:
+		return []Object{}
+	}
+}
+
+// Instantiate template with pattern variables' bindings.
+// Symbols which are not pattern variables are renamed to aliases.
+func (r *SyntaxRules) expand(template Object, bindings patternBindings, aliases map[Object]*Alias, ellipsisEnabled bool) Object {
+	switch {
+	case isIdentifier(template):
+		if binding, ok := bindings[template]; ok {
+			if binding.datum == nil {
+				syntaxError("pattern variable used without ellipsis: %s", template)
+			}
+			return binding.datum
+		}
+		if aliases[template] == nil {
+			aliases[template] = NewAlias(template, r.scope)
+		}
+		return aliases[template]
+	case template.isPair():
+		pair := template.(*Pair)
+
+		// (... template) escapes ellipsis in template
+		if ellipsisEnabled && r.isEllipsis(pair.Car) && pair.Cdr.isPair() {
+			return r.expand(pair.Cdr.(*Pair).Car, bindings, aliases, false)
+		}
+
+		// count ellipses following the element
+		depth, rest := 0, pair.Cdr
+		for ellipsisEnabled && rest.isPair() && r.isEllipsis(rest.(*Pair).Car) {
+			depth++
+			rest = rest.(*Pair).Cdr
+		}
+		if depth == 0 {
+			return &Pair{
+				Car: r.expand(pair.Car, bindings, aliases, ellipsisEnabled),
+				Cdr: r.expand(pair.Cdr, bindings, aliases, ellipsisEnabled),
+			}
+		}
+
+		list := NewPair(nil)
+		for _, element := range r.expandEllipsis(pair.Car, bindings, aliases, depth) {
+			list.Append(element)
+		}
+		expandedRest := r.expand(rest, bindings, aliases, ellipsisEnabled)
+		if list.isNull() {
+			return expandedRest
+		}
+		tail := list
+		for !tail.Cdr.isNull() {
+			tail = tail.Cdr.(*Pair)
+		}
+		tail.Cdr = expandedRest
+		return list
+	case template.isVector():
+		list := r.expand(NewList(nil, template.(*Vector).elements...), bindings, aliases, ellipsisEnabled)
+		return NewVector(list.(*Pair).Elements())
+	default:
+		return template
+	}
+}
+
+// Returns repeated instances of template which is followed by ellipses of given depth.
+func (r *SyntaxRules) expandEllipsis(template Object, bindings patternBindings, aliases map[Object]*Alias, depth int) []Object {
+	variables := []Object{}
+	length := -1
+	for _, variable := range r.patternVariables(template) {
+		binding, ok := bindings[variable]
+		if !ok || binding.datum != nil {
+			continue
+		}
+		if length >= 0 && length != len(binding.sequence) {
+			syntaxError("pattern variables in template have different length: %s", template)
+		}
+		variables = append(variables, variable)
+		length = len(binding.sequence)
+	}
+	if len(variables) == 0 {
+		syntaxError("no pattern variable in template followed by ellipsis: %s", template)
+	}
+
+	results := []Object{}
+	for i := 0; i < length; i++ {
+		iteration := make(patternBindings)
+		for variable, binding := range bindings {
+			iteration[variable] = binding
+		}
+		for _, variable := range variables {
+			iteration[variable] = bindings[variable].sequence[i]
+		}
+
+		if depth == 1 {
+			results = append(results, r.expand(template, iteration, aliases, true))
+		} else {
+			results = append(results, r.expandEllipsis(template, iteration, aliases, depth-1)...)
+		}
+	}
+	return results
+}
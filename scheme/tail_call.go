@@ -0,0 +1,63 @@
+// TailCall is a closure call in tail position which is not invoked yet.
+// Code compiled in tail position returns a tail call instead of calling
+// a closure recursively. A caller which needs the value calls force(),
+// which invokes tail calls in a loop, so that tail recursion does not grow
+// Go's stack.
+
+package scheme
+
+import (
+	"sync/atomic"
+)
+
+// Maximum nesting level of non-tail applications in an evaluation.
+// Go's stack overflow is a fatal error, so too deep recursion is
+// reported as a scheme error before the Go stack is exhausted.
+var maxEvalDepth int64 = 100000
+
+type TailCall struct {
+	ObjectBase
+	closure    *Closure
+	arguments  []Object
+	evaluation *evaluation // evaluation of caller
+}
+
+func NewTailCall(closure *Closure, arguments []Object, evaluation *evaluation) *TailCall {
+	return &TailCall{closure: closure, arguments: arguments, evaluation: evaluation}
+}
+
+func (t *TailCall) String() string {
+	return "#<tail-call>"
+}
+
+// Invoke tail calls until a result is not a tail call.
+func force(object Object) Object {
+	for {
+		tailCall, ok := object.(*TailCall)
+		if !ok {
+			return object
+		}
+		object = tailCall.closure.function(tailCall.arguments, tailCall.evaluation)
+	}
+}
+
+func enterEval(environment *Environment) {
+	evaluation := environment.evaluation
+	evaluation.depth++
+	if evaluation.depth > atomic.LoadInt64(&maxEvalDepth) {
+		evaluation.depth--
+		raiseErrorIn(environment, errorKindStackOverflow, "stack overflow: recursion is too deep")
+	}
+}
+
+func leaveEval(environment *Environment) {
+	environment.evaluation.depth--
+}
+
+// Returns true if handlers can be called in environment after stack overflow.
+// An uncaught stack overflow is reported where it occurred.
+func hasHeadroom(environment *Environment) bool {
+	evaluation := environment.evaluation
+	maxDepth := atomic.LoadInt64(&maxEvalDepth)
+	return evaluation.handlers == nil || evaluation.depth <= maxDepth-maxDepth/10
+}
@@ -0,0 +1,58 @@
+// Values is a type for multiple values returned by values procedure.
+// Single value is not wrapped by Values, so that only receivers of
+// multiple values, such as let-values, need to handle this type.
+
+package scheme
+
+import (
+	"strings"
+)
+
+type Values struct {
+	ObjectBase
+	objects []Object
+}
+
+func NewValues(objects []Object) Object {
+	if len(objects) == 1 {
+		return objects[0]
+	}
+	return &Values{objects: objects}
+}
+
+func (v *Values) String() string {
+	tokens := []string{}
+	for _, object := range v.objects {
+		tokens = append(tokens, object.String())
+	}
+	return strings.Join(tokens, " ")
+}
+
+// Returns objects in values, or a slice of given object if it is a single value.
+func valuesOf(object Object) []Object {
+	if values, ok := object.(*Values); ok {
+		return values.objects
+	}
+	return []Object{object}
+}
+
+// Bind multiple values to slots of formals.
+// If variadic is true, the last slot receives a list of rest values.
+func bindValues(frame *Environment, slots []int, variadic bool, object Object) {
+	objects := valuesOf(object)
+
+	parameters := len(slots)
+	if variadic {
+		parameters--
+		if len(objects) < parameters {
+			raiseErrorIn(frame, errorKindRuntime, "wrong number of values: requires at least %d, but got %d", parameters, len(objects))
+		}
+		frame.put(slots[parameters], NewList(nil, objects[parameters:]...))
+	} else if len(objects) != parameters {
+		raiseErrorIn(frame, errorKindRuntime, "wrong number of values: requires %d, but got %d", parameters, len(objects))
+	}
+
+	for index := 0; index < parameters; index++ {
+		frame.put(slots[index], objects[index])
+	}
+}
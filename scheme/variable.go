@@ -1,13 +1,14 @@
 // Scheme's identifier is classified to a symbol or a variable.
 // And this type owns a role to express a variable.
 // Variable itself does not have a value for identifier,
-// interpreter searches it from its code block scope by Variable's identifier.
+// compiler resolves it to a slot of environment by Variable's identifier.
 
 package scheme
 
 type Variable struct {
 	ObjectBase
 	identifier string
+	alias      *Alias // set when this variable is inserted by syntax-rules
 }
 
 func NewVariable(identifier string, parent Object) *Variable {
@@ -17,21 +18,8 @@ func NewVariable(identifier string, parent Object) *Variable {
 	}
 }
 
-func (v *Variable) Eval() Object {
-	object := v.content()
-	if object == nil {
-		runtimeError("unbound variable: %s", v.identifier)
-	}
-	object.setBounder(v)
-	return object
-}
-
 func (v *Variable) String() string {
-	return v.identifier
-}
-
-func (v *Variable) content() Object {
-	return v.boundedObject(v.identifier)
+	return identifierName(v)
 }
 
 func (v *Variable) isVariable() bool {
@@ -0,0 +1,54 @@
+// Vector is a type for scheme vector object, which is expressed like #(1 2 3).
+// It is backed by a slice, so that its elements are accessed in constant time.
+
+package scheme
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Vector struct {
+	ObjectBase
+	elements []Object
+}
+
+func NewVector(elements []Object, options ...Object) *Vector {
+	if len(options) > 0 {
+		return &Vector{ObjectBase: ObjectBase{parent: options[0]}, elements: elements}
+	} else {
+		return &Vector{elements: elements}
+	}
+}
+
+func (v *Vector) String() string {
+	tokens := []string{}
+	for _, element := range v.elements {
+		tokens = append(tokens, element.String())
+	}
+	return fmt.Sprintf("#(%s)", strings.Join(tokens, " "))
+}
+
+func (v *Vector) isVector() bool {
+	return true
+}
+
+// Returns lists of elements at each index of vectors, up to the shortest one.
+func vectorArgumentLists(vectors []Object) [][]Object {
+	assertObjectsType(vectors, "vector")
+
+	length := -1
+	for _, vector := range vectors {
+		if length < 0 || len(vector.(*Vector).elements) < length {
+			length = len(vector.(*Vector).elements)
+		}
+	}
+
+	argumentLists := make([][]Object, length)
+	for index := range argumentLists {
+		for _, vector := range vectors {
+			argumentLists[index] = append(argumentLists[index], vector.(*Vector).elements[index])
+		}
+	}
+	return argumentLists
+}